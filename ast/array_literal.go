@@ -10,8 +10,11 @@ type ArrayLiteral struct {
 	Elements []Expression
 }
 
+var _ Comparable = &ArrayLiteral{}
 var _ Expression = &ArrayLiteral{}
 
+func (al *ArrayLiteral) validIfCondition() bool { return true }
+
 func (al *ArrayLiteral) expressionNode() {}
 
 func (al *ArrayLiteral) String() string {
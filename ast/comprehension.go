@@ -0,0 +1,62 @@
+package ast
+
+import (
+	"bytes"
+)
+
+// Comprehension builds an array, [u.Email for u in users if u.Active], or a
+// hash, {u.ID: u.Name for (i, u) in users}, from the elements of Iterable.
+type Comprehension struct {
+	TokenAble
+	// Key is the key of each element of a hash, it is nil for an array
+	Key       Expression
+	Value     Expression
+	KeyName   string
+	ValueName string
+	Iterable  Expression
+	Condition Expression
+}
+
+var _ Comparable = &Comprehension{}
+var _ Expression = &Comprehension{}
+
+func (c *Comprehension) validIfCondition() bool { return true }
+
+func (c *Comprehension) expressionNode() {}
+
+// IsHash reports whether the comprehension builds a hash.
+func (c *Comprehension) IsHash() bool {
+	return c.Key != nil
+}
+
+func (c *Comprehension) String() string {
+	var out bytes.Buffer
+
+	if c.IsHash() {
+		out.WriteString("{")
+		out.WriteString(c.Key.String())
+		out.WriteString(": ")
+	} else {
+		out.WriteString("[")
+	}
+	out.WriteString(c.Value.String())
+	out.WriteString(" for (")
+	if c.KeyName != "_" {
+		out.WriteString(c.KeyName)
+		out.WriteString(", ")
+	}
+	out.WriteString(c.ValueName)
+	out.WriteString(") in ")
+	out.WriteString(c.Iterable.String())
+	if c.Condition != nil {
+		out.WriteString(" if ")
+		out.WriteString(c.Condition.String())
+	}
+	if c.IsHash() {
+		out.WriteString("}")
+	} else {
+		out.WriteString("]")
+	}
+
+	return out.String()
+}
@@ -0,0 +1,50 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+)
+
+// FilterExpression pipes the value of Left through a helper, as in
+// value | upcase. Call already holds Left as its first argument.
+type FilterExpression struct {
+	TokenAble
+	Left Expression
+	Call *CallExpression
+}
+
+var _ Comparable = &FilterExpression{}
+var _ Expression = &FilterExpression{}
+
+func (fe *FilterExpression) validIfCondition() bool { return true }
+
+func (fe *FilterExpression) expressionNode() {}
+
+func (fe *FilterExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	if fe.Left != nil {
+		out.WriteString(fe.Left.String())
+	}
+	out.WriteString(" | ")
+	if fe.Call != nil {
+		if fe.Call.Function != nil {
+			out.WriteString(fe.Call.Function.String())
+		}
+		if len(fe.Call.Arguments) > 1 {
+			args := []string{}
+			for _, a := range fe.Call.Arguments[1:] {
+				if a != nil {
+					args = append(args, a.String())
+				}
+			}
+			out.WriteString("(")
+			out.WriteString(strings.Join(args, ", "))
+			out.WriteString(")")
+		}
+	}
+	out.WriteString(")")
+
+	return out.String()
+}
@@ -8,8 +8,10 @@ type ForExpression struct {
 	TokenAble
 	KeyName   string
 	ValueName string
-	Block     *BlockStatement
-	Iterable  Expression
+	// ValuePattern destructures each value, as in for (i, {Name}) in users
+	ValuePattern *Pattern
+	Block        *BlockStatement
+	Iterable     Expression
 }
 
 var _ Expression = &ForExpression{}
@@ -22,7 +24,11 @@ func (fe *ForExpression) String() string {
 	out.WriteString("for (")
 	out.WriteString(fe.KeyName)
 	out.WriteString(", ")
-	out.WriteString(fe.ValueName)
+	if fe.ValuePattern != nil {
+		out.WriteString(fe.ValuePattern.String())
+	} else {
+		out.WriteString(fe.ValueName)
+	}
 	out.WriteString(") in ")
 	if fe.Iterable != nil {
 		out.WriteString(fe.Iterable.String())
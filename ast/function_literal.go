@@ -8,7 +8,12 @@ import (
 type FunctionLiteral struct {
 	TokenAble
 	Parameters []*Identifier
-	Block      *BlockStatement
+	// Defaults holds the default value of each parameter, nil for the
+	// required ones.
+	Defaults []Expression
+	// Rest collects the arguments following the parameters, as in ...rest
+	Rest  *Identifier
+	Block *BlockStatement
 }
 
 var _ Expression = &FunctionLiteral{}
@@ -19,9 +24,16 @@ func (fl *FunctionLiteral) String() string {
 	var out bytes.Buffer
 
 	params := []string{}
-	for _, p := range fl.Parameters {
+	for i, p := range fl.Parameters {
+		if i < len(fl.Defaults) && fl.Defaults[i] != nil {
+			params = append(params, p.String()+" = "+fl.Defaults[i].String())
+			continue
+		}
 		params = append(params, p.String())
 	}
+	if fl.Rest != nil {
+		params = append(params, "..."+fl.Rest.String())
+	}
 
 	out.WriteString(fl.TokenLiteral())
 	out.WriteString("(")
@@ -9,6 +9,7 @@ type Identifier struct {
 	Callee         *Identifier
 	Value          string
 	OriginalCallee *Identifier // So robot.Avatar.Name the OriginalCallee will be robot
+	Safe           bool        // Set for safe navigation, robot?.Avatar
 }
 
 var _ Comparable = &Identifier{}
@@ -23,6 +24,9 @@ func (i *Identifier) String() string {
 
 	if i.Callee != nil {
 		out.WriteString(i.Callee.String())
+		if i.Safe {
+			out.WriteString("?")
+		}
 		out.WriteString(".")
 	}
 
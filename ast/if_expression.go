@@ -2,6 +2,8 @@ package ast
 
 import (
 	"bytes"
+
+	"github.com/gobuffalo/plush/v5/token"
 )
 
 type IfExpression struct {
@@ -22,10 +24,20 @@ type ElseIfExpression struct {
 
 func (ie *IfExpression) expressionNode() {}
 
+// Negated reports whether the expression was written with the unless
+// keyword, in which case its block runs when the condition is falsy.
+func (ie *IfExpression) Negated() bool {
+	return ie.Type == token.UNLESS
+}
+
 func (ie *IfExpression) String() string {
 	var out bytes.Buffer
 
-	out.WriteString("if (")
+	if ie.Negated() {
+		out.WriteString("unless (")
+	} else {
+		out.WriteString("if (")
+	}
 	if ie.Condition != nil {
 		out.WriteString(ie.Condition.String())
 	}
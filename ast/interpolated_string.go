@@ -0,0 +1,38 @@
+package ast
+
+import (
+	"bytes"
+)
+
+// InterpolatedString is a double quoted string embedding expressions,
+// "btn btn-#{kind}". Parts alternate between the *StringLiteral text of the
+// string and the embedded expressions, starting and ending with text.
+type InterpolatedString struct {
+	TokenAble
+	Parts []Expression
+}
+
+var _ Comparable = &InterpolatedString{}
+var _ Expression = &InterpolatedString{}
+
+func (is *InterpolatedString) validIfCondition() bool { return true }
+
+func (is *InterpolatedString) expressionNode() {}
+
+func (is *InterpolatedString) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("\"")
+	for i, p := range is.Parts {
+		if i%2 == 0 {
+			out.WriteString(p.(*StringLiteral).Value)
+			continue
+		}
+		out.WriteString("#{")
+		out.WriteString(p.String())
+		out.WriteString("}")
+	}
+	out.WriteString("\"")
+
+	return out.String()
+}
@@ -6,8 +6,10 @@ import (
 
 type LetStatement struct {
 	TokenAble
-	Name  *Identifier
-	Value Expression
+	Name *Identifier
+	// Pattern replaces Name in let [a, b] = pair and let {a, b} = user
+	Pattern *Pattern
+	Value   Expression
 }
 
 var _ Statement = &LetStatement{}
@@ -20,6 +22,8 @@ func (ls *LetStatement) String() string {
 	out.WriteString(ls.TokenLiteral() + " ")
 	if ls.Name != nil {
 		out.WriteString(ls.Name.String())
+	} else if ls.Pattern != nil {
+		out.WriteString(ls.Pattern.String())
 	}
 	out.WriteString(" = ")
 
@@ -0,0 +1,42 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/gobuffalo/plush/v5/token"
+)
+
+// Pattern destructures a value into several names. [a, b] binds the
+// elements of an array, {a, b} binds the fields of a struct or the keys of
+// a map.
+type Pattern struct {
+	TokenAble
+	Names []*Identifier
+}
+
+// IsArray reports whether the pattern destructures an array
+func (p *Pattern) IsArray() bool {
+	return p.Type == token.LBRACKET
+}
+
+func (p *Pattern) String() string {
+	var out bytes.Buffer
+
+	names := []string{}
+	for _, n := range p.Names {
+		names = append(names, n.String())
+	}
+
+	if p.IsArray() {
+		out.WriteString("[")
+		out.WriteString(strings.Join(names, ", "))
+		out.WriteString("]")
+	} else {
+		out.WriteString("{")
+		out.WriteString(strings.Join(names, ", "))
+		out.WriteString("}")
+	}
+
+	return out.String()
+}
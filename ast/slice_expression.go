@@ -0,0 +1,39 @@
+package ast
+
+import (
+	"bytes"
+)
+
+// SliceExpression is a slice of an array or a string, items[1:3]. Low and
+// High are nil when they are left out.
+type SliceExpression struct {
+	TokenAble
+	Left Expression
+	Low  Expression
+	High Expression
+}
+
+var _ Comparable = &SliceExpression{}
+var _ Expression = &SliceExpression{}
+
+func (se *SliceExpression) validIfCondition() bool { return true }
+
+func (se *SliceExpression) expressionNode() {}
+
+func (se *SliceExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(se.Left.String())
+	out.WriteString("[")
+	if se.Low != nil {
+		out.WriteString(se.Low.String())
+	}
+	out.WriteString(":")
+	if se.High != nil {
+		out.WriteString(se.High.String())
+	}
+	out.WriteString("])")
+
+	return out.String()
+}
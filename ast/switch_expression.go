@@ -0,0 +1,62 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+)
+
+type SwitchExpression struct {
+	TokenAble
+	Subject Expression
+	Cases   []*CaseExpression
+	Default *BlockStatement
+}
+
+var _ Expression = &SwitchExpression{}
+
+type CaseExpression struct {
+	TokenAble
+	Values []Expression
+	Block  *BlockStatement
+}
+
+func (se *SwitchExpression) expressionNode() {}
+
+func (se *SwitchExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("switch (")
+	if se.Subject != nil {
+		out.WriteString(se.Subject.String())
+	}
+	out.WriteString(") { ")
+
+	for _, ce := range se.Cases {
+		if ce == nil {
+			continue
+		}
+		values := []string{}
+		for _, v := range ce.Values {
+			if v != nil {
+				values = append(values, v.String())
+			}
+		}
+		out.WriteString("case ")
+		out.WriteString(strings.Join(values, ", "))
+		out.WriteString(": ")
+		if ce.Block != nil {
+			out.WriteString(ce.Block.String())
+		}
+		out.WriteString(" ")
+	}
+
+	if se.Default != nil {
+		out.WriteString("default: ")
+		out.WriteString(se.Default.String())
+		out.WriteString(" ")
+	}
+
+	out.WriteString("}")
+
+	return out.String()
+}
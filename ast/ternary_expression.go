@@ -0,0 +1,39 @@
+package ast
+
+import (
+	"bytes"
+)
+
+type TernaryExpression struct {
+	TokenAble
+	Condition   Expression
+	Consequence Expression
+	Alternative Expression
+}
+
+var _ Comparable = &TernaryExpression{}
+var _ Expression = &TernaryExpression{}
+
+func (te *TernaryExpression) validIfCondition() bool { return true }
+
+func (te *TernaryExpression) expressionNode() {}
+
+func (te *TernaryExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	if te.Condition != nil {
+		out.WriteString(te.Condition.String())
+	}
+	out.WriteString(" ? ")
+	if te.Consequence != nil {
+		out.WriteString(te.Consequence.String())
+	}
+	out.WriteString(" : ")
+	if te.Alternative != nil {
+		out.WriteString(te.Alternative.String())
+	}
+	out.WriteString(")")
+
+	return out.String()
+}
@@ -0,0 +1,33 @@
+package ast
+
+import (
+	"bytes"
+)
+
+type WhileExpression struct {
+	TokenAble
+	Condition Expression
+	Block     *BlockStatement
+}
+
+var _ Expression = &WhileExpression{}
+
+func (we *WhileExpression) expressionNode() {}
+
+func (we *WhileExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("while (")
+	if we.Condition != nil {
+		out.WriteString(we.Condition.String())
+	}
+	out.WriteString(") { ")
+
+	if we.Block != nil {
+		out.WriteString(we.Block.String())
+	}
+
+	out.WriteString(" }")
+
+	return out.String()
+}
@@ -10,7 +10,8 @@ type BudgetCosts struct {
 	// Default: 5
 	HelperCall int64
 
-	// FilterCall is spent per filter applied (sort, map, where).
+	// FilterCall is spent per filter applied in a pipeline,
+	// e.g. value | upcase | truncate({size: 10}) = 2 filters.
 	// Default: 3
 	FilterCall int64
 
@@ -104,6 +104,18 @@ func TestBudget_ConditionExceedsLimit(t *testing.T) {
 	r.True(errors.Is(err, ErrBudgetExceeded), "expected ErrBudgetExceeded, got %v", err)
 }
 
+func TestBudget_UnlessChargesCondition(t *testing.T) {
+	r := require.New(t)
+	costs := ZeroCosts()
+	costs.ConditionCheck = 5
+
+	tmpl := `<% unless (false) { %>a<% } %><% unless (true) { %>b<% } %>`
+	ctx := NewContext()
+
+	_, err := RenderWithBudgetConfig(tmpl, 7, costs, ctx)
+	r.True(errors.Is(err, ErrBudgetExceeded), "expected ErrBudgetExceeded, got %v", err)
+}
+
 // --- Sub-render shares parent budget (unit test on Budget directly) ---
 
 func TestBudget_SubRenderSharesParentBudget(t *testing.T) {
@@ -283,3 +295,156 @@ func TestBudget_Stats_NilBudgetReturnsZero(t *testing.T) {
 	s := b.Stats()
 	r.Equal(BudgetStats{}, s)
 }
+
+// --- Ternary expressions ---
+
+func TestBudget_TernaryChargesOnlyChosenBranch(t *testing.T) {
+	r := require.New(t)
+	costs := ZeroCosts()
+	costs.ConditionCheck = 1
+	costs.HelperCall = 10
+
+	tmpl := `<%= true ? "a" : myHelper() %>`
+	ctx := NewContext()
+	ctx.Set("myHelper", func() string { return "ok" })
+	b := NewBudgetWithCosts(100, costs)
+	ctx.WithBudget(b)
+
+	s, err := Render(tmpl, ctx)
+	r.NoError(err)
+	r.Equal("a", s)
+
+	stats := b.Stats()
+	r.Equal(int64(1), stats.ConditionChecks)
+	r.Equal(int64(0), stats.FunctionCalls)
+}
+
+// --- Switch expressions ---
+
+func TestBudget_SwitchChargesPerCaseTested(t *testing.T) {
+	r := require.New(t)
+	costs := ZeroCosts()
+	costs.ConditionCheck = 1
+
+	tmpl := `<%= switch ("c") { case "a": %>A<% case "b": %>B<% case "c": %>C<% case "d": %>D<% } %>`
+	ctx := NewContext()
+	b := NewBudgetWithCosts(100, costs)
+	ctx.WithBudget(b)
+
+	s, err := Render(tmpl, ctx)
+	r.NoError(err)
+	r.Equal("C", s)
+	r.Equal(int64(3), b.Stats().ConditionChecks)
+}
+
+// --- Filters ---
+
+func TestBudget_FilterChargesPerStage(t *testing.T) {
+	r := require.New(t)
+	costs := ZeroCosts()
+	costs.FilterCall = 3
+	costs.HelperCall = 100
+
+	tmpl := `<%= "hello world" | upcase | truncate({size: 8}) %>`
+	ctx := NewContext()
+	b := NewBudgetWithCosts(10, costs)
+	ctx.WithBudget(b)
+
+	s, err := Render(tmpl, ctx)
+	r.NoError(err)
+	r.Equal("HELLO...", s)
+
+	stats := b.Stats()
+	r.Equal(int64(6), stats.FilterCalls)
+	r.Equal(int64(0), stats.FunctionCalls)
+}
+
+func TestBudget_ExtendsChargesSubRenderPerLevel(t *testing.T) {
+	r := require.New(t)
+	costs := ZeroCosts()
+	costs.SubRender = 10
+
+	templates := map[string]string{
+		"base.html": `<%= block("title") { %>base<% } %>`,
+		"page.html": `<% extends("base.html") %><%= block("title") { %>page<% } %>`,
+	}
+	ctx := NewContext()
+	ctx.Set("partialFeeder", func(name string) (string, error) {
+		return templates[name], nil
+	})
+	b := NewBudgetWithCosts(15, costs)
+	ctx.WithBudget(b)
+
+	_, err := Render(`<% extends("page.html") %>`, ctx)
+	r.ErrorIs(err, ErrBudgetExceeded)
+	r.Equal(int64(20), b.Stats().SubRenders)
+}
+
+func TestBudget_CallbackChargesPerCall(t *testing.T) {
+	r := require.New(t)
+	costs := ZeroCosts()
+	costs.HelperCall = 1
+
+	ctx := NewContext()
+	ctx.Set("each", func(in []interface{}, f func(interface{}) error) error {
+		for _, v := range in {
+			if err := f(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	b := NewBudgetWithCosts(100, costs)
+	ctx.WithBudget(b)
+
+	_, err := Render(`<% each([1, 2, 3], fn(v) { return v }) %>`, ctx)
+	r.NoError(err)
+	r.Equal(int64(3), b.Stats().ByFunction["fn"])
+	r.Equal(int64(4), b.Stats().FunctionCalls)
+}
+
+func TestBudget_ComprehensionChargesPerElement(t *testing.T) {
+	r := require.New(t)
+	b := NewBudget(1_000)
+	ctx := NewContext()
+	ctx.Set("items", []int{1, 2, 3, 4})
+	ctx.WithBudget(b)
+
+	_, err := Render(`<% let evens = [i for i in items if i % 2 == 0] %><% let m = {i: i for i in items} %>`, ctx)
+	r.NoError(err)
+
+	s := b.Stats()
+	r.Equal(int64(8), s.LoopIterations, "4 elements × 2 comprehensions")
+	r.Equal(int64(4), s.ConditionChecks, "the if of the first comprehension")
+
+	_, err = RenderWithBudget(`<%= [i for i in items] %>`, 3, ctx)
+	r.True(errors.Is(err, ErrBudgetExceeded), "expected ErrBudgetExceeded, got %v", err)
+}
+
+func TestBudget_WhileChargesPerIteration(t *testing.T) {
+	r := require.New(t)
+	b := NewBudget(1_000)
+	ctx := NewContext()
+	ctx.WithBudget(b)
+
+	_, err := Render(`<% let i = 0 %><% while (i < 3) { i = i + 1 } %>`, ctx)
+	r.NoError(err)
+
+	s := b.Stats()
+	r.Equal(int64(3), s.LoopIterations)
+	r.Equal(int64(4), s.ConditionChecks, "3 passing checks and the final one")
+}
+
+func TestBudget_RunawayLoopsAreBounded(t *testing.T) {
+	for _, tmpl := range []string{
+		`<% while (true) { } %>`,
+		`<% for (i) in range(0, 1000000000) { } %>`,
+		`<% for (i) in range(1000000000, 0, -2) { } %>`,
+	} {
+		t.Run(tmpl, func(t *testing.T) {
+			r := require.New(t)
+			_, err := RenderWithBudget(tmpl, 1_000, NewContext())
+			r.True(errors.Is(err, ErrBudgetExceeded), "expected ErrBudgetExceeded, got %v", err)
+		})
+	}
+}
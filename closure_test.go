@@ -0,0 +1,162 @@
+package plush_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gobuffalo/plush/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Closures(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		name     string
+	}{
+		{`<% let adder = fn(n) { return fn(x) { return x + n } } %><% let add2 = adder(2) %><%= add2(3) %>`, "5", "returned_function"},
+		{`<% let adder = fn(n) { return fn(x) { return x + n } } %><%= adder(1)(2) %>`, "3", "immediate_call"},
+		{`<% let x = "defined" %><% let f = fn() { return x } %><%= if (true) { %><% let x = "caller" %><%= f() %><% } %>`, "defined", "lexical_scope"},
+		{`<% let g = fn() { return y } %><% let y = "later" %><%= g() %>`, "later", "defined_later_in_scope"},
+		{`<% let count = 0 %><% let inc = fn() { count = count + 1 } %><% inc() %><% inc() %><%= count %>`, "2", "updates_enclosing"},
+		{`<% let fact = fn(n) { if (n < 2) { return 1 } return n * fact(n - 1) } %><%= fact(5) %>`, "120", "recursion"},
+		{`<% let add = fn(x) { return x + 2 } %><%= add(2) + 1 %>`, "5", "operate_on_result"},
+		{`<% let f = fn(x) { return x } %><%= f("a") == "a" %>`, "true", "compare_result"},
+		{`<% let twice = fn(f, v) { return f(f(v)) } %><%= twice(fn(s) { return s + "!" }, "hi") %>`, "hi!!", "passed_to_function"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			s, err := plush.Render(tc.input, plush.NewContext())
+			r.NoError(err)
+			r.Equal(tc.expected, s)
+		})
+	}
+}
+
+func Test_Closures_Unknown_Caller_Variable(t *testing.T) {
+	r := require.New(t)
+	input := `<% let f = fn() { return secret } %><%= if (true) { %><% let secret = "x" %><%= f() %><% } %>`
+	_, err := plush.Render(input, plush.NewContext())
+	r.Error(err)
+	r.Contains(err.Error(), `"secret": unknown identifier`)
+}
+
+func Test_Closures_PassedToHelpers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		name     string
+	}{
+		{`<%= mapStrings(["a", "b"], fn(s) { return s + prefix }) %>`, "a-,b-", "string_result"},
+		{`<%= mapStrings(["a"], fn(s) { %><b><%= s %></b><% }) %>`, "&lt;b&gt;a&lt;/b&gt;", "rendered_result"},
+		{`<%= sumWith([1, 2], fn(i) { return i * 10 }) %>`, "30", "int_result"},
+		{`<%= each(["a", "b"], fn(s) { return len(s) }) %>`, "2", "no_result"},
+		{`<%= parallel([1, 2, 3], fn(i) { let x = i * 2; return x + offset }) %>`, "15", "concurrent_calls"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			ctx := plush.NewContext()
+			ctx.Set("prefix", "-")
+			ctx.Set("mapStrings", func(in []interface{}, f func(string) (string, error)) (string, error) {
+				out := []string{}
+				for _, s := range in {
+					v, err := f(s.(string))
+					if err != nil {
+						return "", err
+					}
+					out = append(out, v)
+				}
+				return strings.Join(out, ","), nil
+			})
+			ctx.Set("sumWith", func(in []interface{}, f func(int) (int, error)) (int, error) {
+				total := 0
+				for _, i := range in {
+					v, err := f(i.(int))
+					if err != nil {
+						return 0, err
+					}
+					total += v
+				}
+				return total, nil
+			})
+			ctx.Set("each", func(in []interface{}, f func(string) error) (int, error) {
+				for _, s := range in {
+					if err := f(s.(string)); err != nil {
+						return 0, err
+					}
+				}
+				return len(in), nil
+			})
+			ctx.Set("offset", 1)
+			ctx.Set("parallel", func(in []interface{}, f func(int) (int, error)) (int, error) {
+				res := make([]int, len(in))
+				errs := make([]error, len(in))
+				var wg sync.WaitGroup
+				for i, v := range in {
+					wg.Add(1)
+					go func(i, v int) {
+						defer wg.Done()
+						res[i], errs[i] = f(v)
+					}(i, v.(int))
+				}
+				wg.Wait()
+				total := 0
+				for i := range res {
+					if errs[i] != nil {
+						return 0, errs[i]
+					}
+					total += res[i]
+				}
+				return total, nil
+			})
+			s, err := plush.Render(tc.input, ctx)
+			r.NoError(err)
+			r.Equal(tc.expected, s)
+		})
+	}
+}
+
+func Test_Closures_PassedToHelpers_Errors(t *testing.T) {
+	r := require.New(t)
+	ctx := plush.NewContext()
+	ctx.Set("withErr", func(f func() (string, error)) (string, error) {
+		return f()
+	})
+	ctx.Set("withoutErr", func(f func() string) string {
+		return f()
+	})
+	ctx.Set("async", func(f func() error) error {
+		errs := make(chan error)
+		go func() {
+			errs <- f()
+		}()
+		return <-errs
+	})
+
+	_, err := plush.Render(`<%= withErr(fn() { return missing }) %>`, ctx)
+	r.Error(err)
+	r.Contains(err.Error(), `"missing": unknown identifier`)
+
+	_, err = plush.Render(`<%= withoutErr(fn() { return "a" }) %>`, ctx)
+	r.Error(err)
+	r.Contains(err.Error(), `invalid argument for withoutErr at pos 0: fn can only be passed as a function returning an error, expected (func() string)`)
+
+	_, err = plush.Render(`<%= async(fn() { return missing }) %>`, ctx)
+	r.Error(err)
+	r.Contains(err.Error(), `could not call async function`)
+	r.Contains(err.Error(), `"missing": unknown identifier`)
+
+	ctx.Set("wantsInt", func(f func() (int, error)) (string, error) {
+		v, err := f()
+		return fmt.Sprint(v), err
+	})
+	_, err = plush.Render(`<%= wantsInt(fn() { return "a" }) %>`, ctx)
+	r.Error(err)
+	r.Contains(err.Error(), `fn returned string, expected int`)
+}
@@ -8,6 +8,9 @@ import (
 	"github.com/gobuffalo/plush/v5/token"
 
 	"html/template"
+	"math"
+	"math/big"
+	"math/bits"
 	"reflect"
 	"regexp"
 	"strings"
@@ -15,6 +18,7 @@ import (
 
 	"github.com/gobuffalo/plush/v5/ast"
 	"github.com/gobuffalo/plush/v5/helpers/hctx"
+	"github.com/gobuffalo/plush/v5/lexer"
 )
 
 type ErrUnknownIdentifier struct {
@@ -41,6 +45,10 @@ type compiler struct {
 	curStmt           ast.Statement
 	inCheck           bool
 	positionStartEnds []HoleMarker
+	delims            lexer.Delims
+	trimBlocks        bool
+	extends           string
+	blocks            templateBlocks
 }
 
 // budget returns the active Budget from the current context, or nil if unlimited.
@@ -59,6 +67,7 @@ func (c *compiler) compile() (string, error) {
 	for _, stmt := range c.program.Statements {
 		var res interface{}
 		var err error
+		c.curStmt = nil
 
 		switch node := stmt.(type) {
 		case *ast.HoleStatement:
@@ -75,6 +84,7 @@ func (c *compiler) compile() (string, error) {
 				end:         curPost + len(hh),
 				content:     "",
 				err:         nil,
+				delims:      c.delims,
 			}
 			c.positionStartEnds = append(c.positionStartEnds, st)
 		case *ast.ReturnStatement:
@@ -101,6 +111,10 @@ func (c *compiler) compile() (string, error) {
 		c.write(bb, res)
 	}
 
+	if c.extends != "" {
+		return c.renderExtended()
+	}
+
 	content := bb.String()
 	c.fixHolePositions(content)
 	return content, nil
@@ -119,6 +133,7 @@ func (c *compiler) write(bb *strings.Builder, i interface{}) {
 			end:         -1,
 			content:     "",
 			err:         nil,
+			delims:      c.delims,
 		}
 		c.positionStartEnds = append(c.positionStartEnds, st)
 		bb.WriteString(hh)
@@ -159,7 +174,11 @@ func (c *compiler) write(bb *strings.Builder, i interface{}) {
 }
 
 func (c *compiler) evalHoleStatement(node *ast.HoleStatement) (template.HTML, error) {
-	res := "<%= " + node.String() + " %>"
+	d := c.delims
+	if d == (lexer.Delims{}) {
+		d = lexer.DefaultDelims
+	}
+	res := d.Left + "= " + node.String() + " " + d.Right
 	return template.HTML(res), nil
 }
 
@@ -179,8 +198,16 @@ func (c *compiler) evalExpression(node ast.Expression) (interface{}, error) {
 		return c.evalHashLiteral(s)
 	case *ast.IndexExpression:
 		return c.evalIndexExpression(s)
+	case *ast.SliceExpression:
+		return c.evalSliceExpression(s)
+	case *ast.InterpolatedString:
+		return c.evalInterpolatedString(s)
+	case *ast.Comprehension:
+		return c.evalComprehension(s)
 	case *ast.CallExpression:
 		return c.evalCallExpression(s)
+	case *ast.FilterExpression:
+		return c.evalFilterExpression(s)
 	case *ast.Identifier:
 		return c.evalIdentifier(s)
 	case *ast.Boolean:
@@ -189,8 +216,14 @@ func (c *compiler) evalExpression(node ast.Expression) (interface{}, error) {
 		return c.evalArrayLiteral(s)
 	case *ast.ForExpression:
 		return c.evalForExpression(s)
+	case *ast.WhileExpression:
+		return c.evalWhileExpression(s)
 	case *ast.IfExpression:
 		return c.evalIfExpression(s)
+	case *ast.TernaryExpression:
+		return c.evalTernaryExpression(s)
+	case *ast.SwitchExpression:
+		return c.evalSwitchExpression(s)
 	case *ast.PrefixExpression:
 		return c.evalPrefixExpression(s)
 	case *ast.FunctionLiteral:
@@ -225,28 +258,148 @@ func (c *compiler) evalAssignExpression(node *ast.AssignExpression) (interface{}
 	return nil, nil
 }
 
-func (c *compiler) evalUserFunction(node *userFunction, args []ast.Expression) (interface{}, error) {
+func (c *compiler) evalUserFunction(name string, node *userFunction, args []ast.Expression) (interface{}, error) {
+	values := make([]interface{}, 0, len(args))
+	for _, a := range args {
+		v, err := c.evalExpression(a)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+
+	return c.callUserFunction(name, node, values)
+}
+
+// callUserFunction runs the function in a new scope of the context it was
+// defined in, with its parameters set to args. Missing arguments take the
+// default value of their parameter, evaluated in that scope.
+func (c *compiler) callUserFunction(name string, node *userFunction, args []interface{}) (interface{}, error) {
+	if err := node.checkArity(name, len(args)); err != nil {
+		return nil, err
+	}
+
 	octx := c.ctx
 	defer func() { c.ctx = octx }()
 
-	c.ctx = c.ctx.New()
+	env := node.env
+	if env == nil {
+		env = c.ctx
+	}
+	c.ctx = env.New()
 	for i, p := range node.Parameters {
-		a := args[i]
-		v, err := c.evalExpression(a)
+		if i < len(args) {
+			c.ctx.Set(p.Value, args[i])
+			continue
+		}
+		v, err := c.evalExpression(node.Defaults[i])
 		if err != nil {
 			return nil, err
 		}
-
 		c.ctx.Set(p.Value, v)
 	}
+	if node.Rest != nil {
+		rest := []interface{}{}
+		if len(args) > len(node.Parameters) {
+			rest = append(rest, args[len(node.Parameters):]...)
+		}
+		c.ctx.Set(node.Rest.Value, rest)
+	}
+
+	res, err := c.evalBlockStatement(node.Block)
+	if err != nil {
+		return nil, err
+	}
+
+	// a function returning a value without printing anything before
+	// evaluates to that value, so it can be operated on or called.
+	for {
+		ro, ok := res.(returnObject)
+		if !ok || len(ro.Value) != 1 {
+			return res, nil
+		}
+		res = ro.Value[0]
+	}
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// argValue returns v as an argument of type t. User functions passed where
+// a Go function is expected are wrapped into one, which must return an
+// error as its last result so the errors of the user function reach the
+// template.
+func (c *compiler) argValue(v interface{}, t reflect.Type) (reflect.Value, error) {
+	if f, ok := v.(*userFunction); ok && t.Kind() == reflect.Func {
+		if t.NumOut() == 0 || t.Out(t.NumOut()-1) != errorType {
+			return reflect.Value{}, fmt.Errorf("fn can only be passed as a function returning an error, expected (%s)", t)
+		}
+		return c.wrapUserFunction(f, t), nil
+	}
+	return reflect.ValueOf(v), nil
+}
+
+// wrapUserFunction returns a Go function of type t calling the user
+// function. Its result is converted to the first result of t, rendered when
+// t expects a string, and errors are returned as the last result of t.
+// Each call runs on its own compiler, so a helper may call it from another
+// goroutine.
+func (c *compiler) wrapUserFunction(f *userFunction, t reflect.Type) reflect.Value {
+	ctx := c.ctx
+
+	return reflect.MakeFunc(t, func(in []reflect.Value) []reflect.Value {
+		args := make([]interface{}, 0, len(in))
+		for _, a := range in {
+			args = append(args, a.Interface())
+		}
+
+		fc := &compiler{
+			ctx:        ctx,
+			program:    c.program,
+			delims:     c.delims,
+			trimBlocks: c.trimBlocks,
+		}
+
+		var res interface{}
+		err := fc.budget().SpendFunctionCall("fn")
+		if err == nil {
+			res, err = fc.callUserFunction("fn", f, args)
+		}
+
+		out := make([]reflect.Value, t.NumOut())
+		for i := range out {
+			ot := t.Out(i)
+			out[i] = reflect.Zero(ot)
+			if i > 0 || ot == errorType || err != nil {
+				continue
+			}
+			if _, ok := res.(string); !ok && ot.Kind() == reflect.String && res != nil {
+				bb := &strings.Builder{}
+				fc.write(bb, res)
+				res = bb.String()
+			}
+			rres := reflect.ValueOf(res)
+			switch {
+			case res == nil:
+			case rres.Type().AssignableTo(ot):
+				out[i] = rres
+			case rres.Type().ConvertibleTo(ot):
+				out[i] = rres.Convert(ot)
+			default:
+				err = fmt.Errorf("fn returned %T, expected %s", res, ot)
+			}
+		}
 
-	return c.evalBlockStatement(node.Block)
+		if err != nil {
+			out[t.NumOut()-1] = reflect.ValueOf(&err).Elem()
+		}
+		return out
+	})
 }
 
 func (c *compiler) evalFunctionLiteral(node *ast.FunctionLiteral) (interface{}, error) {
 	params := node.Parameters
 	block := node.Block
-	return &userFunction{Parameters: params, Block: block}, nil
+	return &userFunction{Parameters: params, Defaults: node.Defaults, Rest: node.Rest, Block: block, env: c.ctx}, nil
 }
 
 func (c *compiler) evalPrefixExpression(node *ast.PrefixExpression) (interface{}, error) {
@@ -260,6 +413,14 @@ func (c *compiler) evalPrefixExpression(node *ast.PrefixExpression) (interface{}
 	switch node.Operator {
 	case "!":
 		return !c.isTruthy(res), nil
+	case "-":
+		if v := reflect.ValueOf(res); isNumber(v) {
+			return c.numbersOperator(reflect.ValueOf(0), v, "-")
+		}
+	case "+":
+		if isNumber(reflect.ValueOf(res)) {
+			return res, nil
+		}
 	}
 
 	return nil, fmt.Errorf("unknown operator %s", node.Operator)
@@ -282,13 +443,78 @@ func (c *compiler) evalIfExpression(node *ast.IfExpression) (interface{}, error)
 		}
 	}
 
-	if c.isTruthy(con) {
+	if c.isTruthy(con) != node.Negated() {
 		return c.evalBlockStatement(node.Block)
 	}
 
 	return c.evalElseAndElseIfExpressions(node)
 }
 
+func (c *compiler) evalTernaryExpression(node *ast.TernaryExpression) (interface{}, error) {
+	if err := c.budget().SpendCondition(); err != nil {
+		return nil, err
+	}
+
+	con, err := c.evalExpression(node.Condition)
+	if err != nil {
+		if _, ok := err.(*ErrUnknownIdentifier); !ok {
+			return nil, err
+		}
+	}
+
+	// only the selected branch is evaluated
+	if c.isTruthy(con) {
+		return c.evalExpression(node.Consequence)
+	}
+
+	return c.evalExpression(node.Alternative)
+}
+
+func (c *compiler) evalSwitchExpression(node *ast.SwitchExpression) (interface{}, error) {
+	octx := c.ctx.(*Context)
+	defer func() {
+		c.ctx = octx
+	}()
+
+	c.ctx = octx.New()
+	subject, err := c.evalExpression(node.Subject)
+	if err != nil {
+		if _, ok := err.(*ErrUnknownIdentifier); !ok {
+			return nil, err
+		}
+	}
+
+	for _, ce := range node.Cases {
+		if err := c.budget().SpendCondition(); err != nil {
+			return nil, err
+		}
+
+		for _, ve := range ce.Values {
+			v, err := c.evalExpression(ve)
+			if err != nil {
+				if _, ok := err.(*ErrUnknownIdentifier); !ok {
+					return nil, err
+				}
+			}
+
+			eq, err := c.operate(subject, v, "==")
+			if err != nil {
+				return nil, err
+			}
+
+			if c.isTruthy(eq) {
+				return c.evalBlockStatement(ce.Block)
+			}
+		}
+	}
+
+	if node.Default != nil {
+		return c.evalBlockStatement(node.Default)
+	}
+
+	return nil, nil
+}
+
 func (c *compiler) evalElseAndElseIfExpressions(node *ast.IfExpression) (interface{}, error) {
 	var r interface{}
 	for _, eiNode := range node.ElseIf {
@@ -311,6 +537,16 @@ func (c *compiler) evalElseAndElseIfExpressions(node *ast.IfExpression) (interfa
 	return r, nil
 }
 
+// isNil reports whether i is nil or a nil pointer.
+func isNil(i interface{}) bool {
+	if i == nil {
+		return true
+	}
+
+	rv := reflect.ValueOf(i)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
 func (c *compiler) isTruthy(i interface{}) bool {
 	if i == nil {
 		return false
@@ -343,6 +579,11 @@ func (c *compiler) evalIndexExpression(node *ast.IndexExpression) (interface{},
 		return nil, err
 	}
 
+	// a?.b[0] short-circuits to nil like a?.b does
+	if id, ok := node.Left.(*ast.Identifier); ok && id.Safe && isNil(left) && node.Value == nil {
+		return nil, nil
+	}
+
 	var value interface{}
 
 	if node.Value != nil {
@@ -365,9 +606,10 @@ func (c *compiler) evalUpdateIndex(left, index, value interface{}) error {
 		rv.SetMapIndex(reflect.ValueOf(index), reflect.ValueOf(value))
 	case reflect.Array, reflect.Slice:
 		if i, ok := index.(int); ok {
-			if rv.Len()-1 < i {
+			if n, ok := sequenceIndex(i, rv.Len()); !ok {
 				err = fmt.Errorf("array index out of bounds, got index %d, while array size is %v", i, rv.Len())
 			} else {
+				i = n
 				elemType := reflect.TypeOf(left).Elem()
 				if elemType.Kind() != reflect.Interface {
 					t := reflect.ValueOf(value).Type()
@@ -415,10 +657,10 @@ func (c *compiler) evalAccessIndex(left, index interface{}, node *ast.IndexExpre
 		}
 	case reflect.Array, reflect.Slice:
 		if i, ok := index.(int); ok {
-			if rv.Len()-1 < i {
+			if n, ok := sequenceIndex(i, rv.Len()); !ok {
 				err = fmt.Errorf("array index out of bounds, got index %d, while array size is %d", index, rv.Len())
 			} else {
-
+				i = n
 				if node.Callee != nil {
 					returnValue, err = c.evalIndexCallee(rv.Index(i), node)
 				} else {
@@ -428,6 +670,17 @@ func (c *compiler) evalAccessIndex(left, index interface{}, node *ast.IndexExpre
 		} else {
 			err = fmt.Errorf("can't access Slice/Array with a non int Index (%v)", index)
 		}
+	case reflect.String:
+		i, ok := index.(int)
+		if !ok {
+			return nil, fmt.Errorf("can't access String with a non int Index (%v)", index)
+		}
+		runes := []rune(rv.String())
+		n, ok := sequenceIndex(i, len(runes))
+		if !ok {
+			return nil, fmt.Errorf("string index out of bounds, got index %d, while string length is %d", i, len(runes))
+		}
+		returnValue = string(runes[n])
 	default:
 		err = fmt.Errorf("could not index %T with %T", left, index)
 	}
@@ -435,6 +688,134 @@ func (c *compiler) evalAccessIndex(left, index interface{}, node *ast.IndexExpre
 	return returnValue, err
 }
 
+// sequenceIndex resolves index i of a sequence of length n, negative indexes
+// count back from the end. It reports false if i is out of range.
+func sequenceIndex(i, n int) (int, bool) {
+	if i < 0 {
+		i += n
+	}
+	return i, i >= 0 && i < n
+}
+
+// evalSliceExpression slices an array, a slice or a string, by rune. Negative
+// bounds count back from the end and bounds past either end are clamped, so
+// items[:5] holds at most five elements.
+func (c *compiler) evalSliceExpression(node *ast.SliceExpression) (interface{}, error) {
+	left, err := c.evalExpression(node.Left)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := reflect.ValueOf(left)
+	var runes []rune
+	n := 0
+	switch rv.Kind() {
+	case reflect.Array, reflect.Slice:
+		n = rv.Len()
+	case reflect.String:
+		runes = []rune(rv.String())
+		n = len(runes)
+	default:
+		return nil, fmt.Errorf("could not slice %T", left)
+	}
+
+	low, err := c.sliceBound(node.Low, 0, n)
+	if err != nil {
+		return nil, err
+	}
+	high, err := c.sliceBound(node.High, n, n)
+	if err != nil {
+		return nil, err
+	}
+	if high < low {
+		high = low
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		return string(runes[low:high]), nil
+	case reflect.Array:
+		// arrays held in an interface are not addressable, copy them
+		res := reflect.MakeSlice(reflect.SliceOf(rv.Type().Elem()), high-low, high-low)
+		for i := low; i < high; i++ {
+			res.Index(i - low).Set(rv.Index(i))
+		}
+		return res.Interface(), nil
+	}
+	return rv.Slice(low, high).Interface(), nil
+}
+
+// sliceBound evaluates a bound of a slice of a sequence of length n, def is
+// used when the bound is left out.
+func (c *compiler) sliceBound(node ast.Expression, def, n int) (int, error) {
+	if node == nil {
+		return def, nil
+	}
+	v, err := c.evalExpression(node)
+	if err != nil {
+		return 0, err
+	}
+	i, ok := v.(int)
+	if !ok {
+		return 0, fmt.Errorf("can't slice with a non int bound (%v)", v)
+	}
+	if i < 0 {
+		i += n
+	}
+	if i < 0 {
+		return 0, nil
+	}
+	if i > n {
+		return n, nil
+	}
+	return i, nil
+}
+
+func (c *compiler) evalInterpolatedString(node *ast.InterpolatedString) (interface{}, error) {
+	var bb strings.Builder
+	for i, p := range node.Parts {
+		if i%2 == 0 {
+			bb.WriteString(p.(*ast.StringLiteral).Value)
+			continue
+		}
+
+		v, err := c.evalExpression(p)
+		if err != nil {
+			return nil, err
+		}
+		bb.WriteString(c.interpolate(v))
+	}
+
+	return bb.String(), nil
+}
+
+// interpolate formats a value embedded in a string, nil is formatted as an
+// empty string and times use the TIME_FORMAT of the context.
+func (c *compiler) interpolate(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case template.HTML:
+		return string(t)
+	case *time.Time:
+		if t == nil {
+			return ""
+		}
+		return c.interpolate(*t)
+	case time.Time:
+		if dtf, ok := c.ctx.Value("TIME_FORMAT").(string); ok {
+			return t.Format(dtf)
+		}
+		return t.Format(DefaultTimeFormat)
+	case HTMLer:
+		return string(t.HTML())
+	}
+
+	return fmt.Sprint(v)
+}
+
 func (c *compiler) evalHashLiteral(node *ast.HashLiteral) (interface{}, error) {
 	m := map[string]interface{}{}
 	for ke, ve := range node.Pairs {
@@ -458,29 +839,102 @@ func (c *compiler) evalLetStatement(node *ast.LetStatement) (interface{}, error)
 		return nil, err
 	}
 
+	if node.Pattern != nil {
+		return nil, c.destructure(node.Pattern, node.Value.String(), v)
+	}
+
 	c.ctx.Set(node.Name.Value, v)
 	return nil, nil
 }
 
+// destructure binds the names of the pattern to the elements, fields or
+// keys of v, which is described by subject in errors.
+func (c *compiler) destructure(p *ast.Pattern, subject string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return fmt.Errorf("can not destructure nil '%s' into %s", subject, p)
+		}
+		rv = rv.Elem()
+	}
+
+	if p.IsArray() {
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return fmt.Errorf("can not destructure '%s' (%T) into %s", subject, v, p)
+		}
+		if rv.Len() < len(p.Names) {
+			return fmt.Errorf("can not destructure '%s' into %s, it has %d elements", subject, p, rv.Len())
+		}
+		for i, n := range p.Names {
+			c.ctx.Set(n.Value, rv.Index(i).Interface())
+		}
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Map:
+		kt := rv.Type().Key()
+		if kt.Kind() != reflect.String {
+			return fmt.Errorf("can not destructure '%s' (%T) into %s", subject, v, p)
+		}
+		for _, n := range p.Names {
+			f := rv.MapIndex(reflect.ValueOf(n.Value).Convert(kt))
+			if !f.IsValid() {
+				return fmt.Errorf("'%s' does not have a key named '%s' (%s)", subject, n.Value, p)
+			}
+			c.ctx.Set(n.Value, f.Interface())
+		}
+	case reflect.Struct:
+		for _, n := range p.Names {
+			f := rv.FieldByName(n.Value)
+			if !f.IsValid() || !f.CanInterface() {
+				return fmt.Errorf("'%s' does not have a field named '%s' (%s)", subject, n.Value, p)
+			}
+			c.ctx.Set(n.Value, f.Interface())
+		}
+	default:
+		return fmt.Errorf("can not destructure '%s' (%T) into %s", subject, v, p)
+	}
+	return nil
+}
+
+// setForValue binds the value of a for loop iteration
+func (c *compiler) setForValue(node *ast.ForExpression, v interface{}) error {
+	if node.ValuePattern != nil {
+		return c.destructure(node.ValuePattern, node.Iterable.String()+" element", v)
+	}
+	c.ctx.Set(node.ValueName, v)
+	return nil
+}
+
 func (c *compiler) evalIdentifier(node *ast.Identifier) (interface{}, error) {
 	if node.Callee != nil {
 		if err := c.budget().SpendObjectTraversal(1); err != nil {
 			return nil, err
 		}
-		c, err := c.evalExpression(node.Callee)
+		cv, err := c.evalExpression(node.Callee)
 		if err != nil {
-			return nil, err
+			if _, ok := err.(*ErrUnknownIdentifier); !ok || !node.Safe {
+				return nil, err
+			}
 		}
 
-		rv := reflect.ValueOf(c)
+		rv := reflect.ValueOf(cv)
 		if !rv.IsValid() {
 			return nil, nil
 		}
 
 		if rv.Kind() == reflect.Ptr {
+			if node.Safe && rv.IsNil() {
+				return nil, nil
+			}
 			rv = rv.Elem()
 		}
 
+		if rv.Kind() == reflect.Map {
+			return c.evalMapField(rv, node)
+		}
+
 		if rv.Kind() != reflect.Struct {
 			return nil, fmt.Errorf("'%s' does not have a field or method named '%s' (%s)", node.Callee.String(), node.Value, node)
 		}
@@ -523,72 +977,201 @@ func (c *compiler) evalIdentifier(node *ast.Identifier) (interface{}, error) {
 	}
 }
 
-func (c *compiler) evalInfixExpression(node *ast.InfixExpression) (interface{}, error) {
-	lres, err := c.evalExpression(node.Left)
-	if err != nil &&
-		node.Operator != "==" && node.Operator != "!=" &&
-		node.Operator != "||" && node.Operator != "&&" {
-		return nil, err
-	} // nil lres is acceptable only for '==', '!=', and logical operators
-
-	switch { // fast return
-	case node.Operator == "&&" && !c.isTruthy(lres):
-		return false, nil
-	case node.Operator == "||" && c.isTruthy(lres):
-		return true, nil
+// evalMapField resolves a.b where a is a map with string keys. A missing key
+// is an error, unless it was reached through safe navigation (a?.b).
+func (c *compiler) evalMapField(rv reflect.Value, node *ast.Identifier) (interface{}, error) {
+	kt := rv.Type().Key()
+	if kt.Kind() != reflect.String {
+		return nil, fmt.Errorf("'%s' does not have a field or method named '%s' (%s)", node.Callee.String(), node.Value, node)
 	}
 
-	rres, err := c.evalExpression(node.Right)
-	if err != nil &&
-		node.Operator != "==" && node.Operator != "!=" &&
-		node.Operator != "||" && node.Operator != "&&" {
-		return nil, err
-	} // nil rres is acceptable only for '==', '!=', and logical operators
-
-	switch node.Operator {
-	case "&&", "||":
-		return c.isTruthy(rres), nil
-	} // fast return or this. '&&' and '||' end here
+	v := rv.MapIndex(reflect.ValueOf(node.Value).Convert(kt))
+	if v.IsValid() {
+		return v.Interface(), nil
+	}
 
-	if nil == lres || nil == rres {
-		return c.nilsOperator(lres, rres, node.Operator)
+	if m := rv.MethodByName(node.Value); m.IsValid() {
+		return m.Interface(), nil
 	}
 
-	switch t := lres.(type) {
-	case string:
-		return c.stringsOperator(t, rres, node.Operator)
-	case int64:
-		if r, ok := rres.(int64); ok {
-			return c.intsOperator(int(t), int(r), node.Operator)
-		}
-	case int:
-		if r, ok := rres.(int); ok {
-			return c.intsOperator(t, r, node.Operator)
-		}
-	case float64:
-		if r, ok := rres.(float64); ok {
-			return c.floatsOperator(t, r, node.Operator)
-		}
-	case bool:
-		return c.boolsOperator(lres, rres, node.Operator)
-	default:
-		if reflect.TypeOf(t).Kind() == reflect.Slice || reflect.TypeOf(t).Kind() == reflect.Array {
-			return c.arrayOperator(lres, rres, node.Operator)
-		}
+	if node.Safe {
+		return nil, nil
 	}
 
-	return nil, fmt.Errorf("unable to operate (%s) on %T and %T ", node.Operator, lres, rres)
+	return nil, fmt.Errorf("'%s' does not have a key named '%s' (%s)", node.Callee.String(), node.Value, node)
 }
 
-func (c *compiler) arrayOperator(l, r interface{}, op string) (interface{}, error) {
-	switch op {
-	case "+":
-		lv := reflect.ValueOf(l)
-		if lv.Kind() != reflect.Slice && lv.Kind() != reflect.Array {
-			return nil, fmt.Errorf("left operand must be a slice/array, got %T", l)
+func (c *compiler) evalNullishExpression(node *ast.InfixExpression) (interface{}, error) {
+	lres, err := c.evalExpression(node.Left)
+	if err != nil {
+		if _, ok := err.(*ErrUnknownIdentifier); !ok {
+			return nil, err
 		}
-		elem := lv.Type().Elem()
-		rv := reflect.ValueOf(r)
+	}
+
+	if !isNil(lres) {
+		return lres, nil
+	}
+
+	return c.evalExpression(node.Right)
+}
+
+func (c *compiler) evalInExpression(node *ast.InfixExpression) (interface{}, error) {
+	lres, err := c.evalExpression(node.Left)
+	if err != nil {
+		return nil, err
+	}
+
+	rres, err := c.evalExpression(node.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := c.contains(rres, lres)
+	if err != nil {
+		return nil, err
+	}
+
+	if node.Operator == "not in" {
+		return !found, nil
+	}
+
+	return found, nil
+}
+
+// contains reports whether item is an element of a slice or array, a key of
+// a map, or a substring of a string. Elements are compared following the
+// rules of the == operator.
+func (c *compiler) contains(container, item interface{}) (bool, error) {
+	if container == nil {
+		return false, nil
+	}
+
+	if s, ok := container.(string); ok {
+		sub, ok := item.(string)
+		if !ok {
+			return false, fmt.Errorf("cannot check %T in string", item)
+		}
+		return strings.Contains(s, sub), nil
+	}
+
+	rv := reflect.Indirect(reflect.ValueOf(container))
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			eq, err := c.operate(rv.Index(i).Interface(), item, "==")
+			if err != nil {
+				// elements of a different type never match
+				continue
+			}
+			if c.isTruthy(eq) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case reflect.Map:
+		kt := rv.Type().Key()
+		if item == nil {
+			return false, nil
+		}
+		iv := reflect.ValueOf(item)
+		if !iv.Type().AssignableTo(kt) {
+			return false, fmt.Errorf("cannot use %v (%s constant) as %s value in map index", item, iv.Kind(), kt.Kind())
+		}
+		return rv.MapIndex(iv).IsValid(), nil
+	}
+
+	return false, fmt.Errorf("cannot check membership in %T", container)
+}
+
+func (c *compiler) evalInfixExpression(node *ast.InfixExpression) (interface{}, error) {
+	switch node.Operator {
+	case "??":
+		return c.evalNullishExpression(node)
+	case "in", "not in":
+		return c.evalInExpression(node)
+	}
+
+	lres, err := c.evalExpression(node.Left)
+	if err != nil &&
+		node.Operator != "==" && node.Operator != "!=" &&
+		node.Operator != "||" && node.Operator != "&&" {
+		return nil, err
+	} // nil lres is acceptable only for '==', '!=', and logical operators
+
+	switch { // fast return
+	case node.Operator == "&&" && !c.isTruthy(lres):
+		return false, nil
+	case node.Operator == "||" && c.isTruthy(lres):
+		return true, nil
+	}
+
+	rres, err := c.evalExpression(node.Right)
+	if err != nil &&
+		node.Operator != "==" && node.Operator != "!=" &&
+		node.Operator != "||" && node.Operator != "&&" {
+		return nil, err
+	} // nil rres is acceptable only for '==', '!=', and logical operators
+
+	switch node.Operator {
+	case "&&", "||":
+		return c.isTruthy(rres), nil
+	} // fast return or this. '&&' and '||' end here
+
+	return c.operate(lres, rres, node.Operator)
+}
+
+// operate applies a binary operator to two already evaluated operands.
+func (c *compiler) operate(lres, rres interface{}, op string) (interface{}, error) {
+	// a nil *time.Time is operated on as nil, not as a time
+	if t, ok := lres.(*time.Time); ok && t == nil {
+		lres = nil
+	}
+	if t, ok := rres.(*time.Time); ok && t == nil {
+		rres = nil
+	}
+
+	if nil == lres || nil == rres {
+		return c.nilsOperator(lres, rres, op)
+	}
+
+	if isTime(lres) || isTime(rres) {
+		return c.timesOperator(lres, rres, op)
+	}
+
+	if lv, rv := reflect.ValueOf(lres), reflect.ValueOf(rres); isNumber(lv) && isNumber(rv) {
+		return c.numbersOperator(lv, rv, op)
+	}
+
+	// a string on the left is compared to the printed right operand, so
+	// that it still equals a uuid or any other Stringer with the same text
+	if _, ok := lres.(string); !ok && (op == "==" || op == "!=") && (isComposite(lres) || isComposite(rres)) {
+		return c.valuesEqual(reflect.ValueOf(lres), reflect.ValueOf(rres)) == (op == "=="), nil
+	}
+
+	switch t := lres.(type) {
+	case string:
+		return c.stringsOperator(t, rres, op)
+	case bool:
+		return c.boolsOperator(lres, rres, op)
+	default:
+		if reflect.TypeOf(t).Kind() == reflect.Slice || reflect.TypeOf(t).Kind() == reflect.Array {
+			return c.arrayOperator(lres, rres, op)
+		}
+	}
+
+	return nil, fmt.Errorf("unable to operate (%s) on %T and %T ", op, lres, rres)
+}
+
+func (c *compiler) arrayOperator(l, r interface{}, op string) (interface{}, error) {
+	switch op {
+	case "+":
+		lv := reflect.ValueOf(l)
+		if lv.Kind() != reflect.Slice && lv.Kind() != reflect.Array {
+			return nil, fmt.Errorf("left operand must be a slice/array, got %T", l)
+		}
+		elem := lv.Type().Elem()
+		rv := reflect.ValueOf(r)
 
 		if elem.Kind() == reflect.Interface {
 			return reflect.Append(lv, rv).Interface(), nil
@@ -636,19 +1219,467 @@ func (c *compiler) boolsOperator(l interface{}, r interface{}, op string) (inter
 	}
 }
 
+// isComposite reports whether v is a struct, a slice, an array, a map or a
+// pointer, or has an Equal method.
+func isComposite(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map, reflect.Ptr:
+		return true
+	}
+	_, ok := equalMethod(rv, rv)
+	return ok
+}
+
+// equalMethod returns the Equal(other) bool method of l if r can be passed
+// to it.
+func equalMethod(l, r reflect.Value) (reflect.Value, bool) {
+	if !l.CanInterface() || !r.CanInterface() {
+		return reflect.Value{}, false
+	}
+	m := l.MethodByName("Equal")
+	if !m.IsValid() {
+		return reflect.Value{}, false
+	}
+	t := m.Type()
+	if t.NumIn() != 1 || t.NumOut() != 1 || t.Out(0).Kind() != reflect.Bool || !r.Type().AssignableTo(t.In(0)) {
+		return reflect.Value{}, false
+	}
+	return m, true
+}
+
+// visit is a pair of pointers, maps or slices compared by valuesEqual.
+type visit struct {
+	l, r uintptr
+	typ  reflect.Type
+}
+
+// valuesEqual reports whether l and r are deeply equal. The Equal(other)
+// bool method of a value is used when it has one, numbers of any kind are
+// compared by value, and slices and arrays, maps and structs are compared
+// element by element, key by key and field by field. Pointers are compared
+// by the values they point to.
+func (c *compiler) valuesEqual(l, r reflect.Value) bool {
+	return c.deepEqual(l, r, map[visit]bool{})
+}
+
+// deepEqual is valuesEqual, keeping track of the pairs of pointers, maps
+// and slices already being compared so that values referring back to
+// themselves are not compared forever. A pair seen again is assumed equal,
+// as reflect.DeepEqual does.
+func (c *compiler) deepEqual(l, r reflect.Value, visited map[visit]bool) bool {
+	for l.Kind() == reflect.Interface {
+		l = l.Elem()
+	}
+	for r.Kind() == reflect.Interface {
+		r = r.Elem()
+	}
+	if !l.IsValid() || !r.IsValid() {
+		return l.IsValid() == r.IsValid()
+	}
+
+	switch l.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if l.Kind() == r.Kind() {
+			v := visit{l: l.Pointer(), r: r.Pointer(), typ: l.Type()}
+			if visited[v] {
+				return true
+			}
+			visited[v] = true
+		}
+	}
+
+	if m, ok := equalMethod(l, r); ok {
+		return m.Call([]reflect.Value{r})[0].Bool()
+	}
+	if m, ok := equalMethod(r, l); ok {
+		return m.Call([]reflect.Value{l})[0].Bool()
+	}
+
+	if isNumber(l) && isNumber(r) {
+		res, err := c.numbersOperator(l, r, "==")
+		return err == nil && res == true
+	}
+
+	if l.Kind() == reflect.Ptr || r.Kind() == reflect.Ptr {
+		if l.Kind() == r.Kind() && l.Pointer() == r.Pointer() {
+			return true
+		}
+		if (l.Kind() == reflect.Ptr && l.IsNil()) || (r.Kind() == reflect.Ptr && r.IsNil()) {
+			return false
+		}
+		return c.deepEqual(reflect.Indirect(l), reflect.Indirect(r), visited)
+	}
+
+	switch l.Kind() {
+	case reflect.Slice, reflect.Array:
+		if r.Kind() != reflect.Slice && r.Kind() != reflect.Array || l.Len() != r.Len() {
+			return false
+		}
+		for i := 0; i < l.Len(); i++ {
+			if !c.deepEqual(l.Index(i), r.Index(i), visited) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		if r.Kind() != reflect.Map || l.Len() != r.Len() {
+			return false
+		}
+		kt := r.Type().Key()
+		for _, k := range l.MapKeys() {
+			if !k.Type().AssignableTo(kt) && !(isNumber(k) && isNumber(reflect.Zero(kt))) {
+				return false
+			}
+			// a numeric key has to keep its exact value in the other key type
+			ck := k.Convert(kt)
+			if !c.deepEqual(k, ck, visited) {
+				return false
+			}
+			v := r.MapIndex(ck)
+			if !v.IsValid() || !c.deepEqual(l.MapIndex(k), v, visited) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		if l.Type() != r.Type() {
+			return false
+		}
+		for i := 0; i < l.NumField(); i++ {
+			if !c.deepEqual(l.Field(i), r.Field(i), visited) {
+				return false
+			}
+		}
+		return true
+	case reflect.String:
+		return r.Kind() == reflect.String && l.String() == r.String()
+	case reflect.Bool:
+		return r.Kind() == reflect.Bool && l.Bool() == r.Bool()
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return l.Type() == r.Type() && l.Pointer() == r.Pointer()
+	}
+
+	return false
+}
+
+// isTime reports whether v is a time.Time, a *time.Time or a time.Duration.
+func isTime(v interface{}) bool {
+	switch v.(type) {
+	case time.Time, *time.Time, time.Duration:
+		return true
+	}
+	return false
+}
+
+// timesOperator applies op to times and durations. Times can be compared,
+// subtracted from each other into a duration, and moved by a duration.
+// Durations can be compared, added, subtracted and multiplied or divided by
+// a number.
+func (c *compiler) timesOperator(l, r interface{}, op string) (interface{}, error) {
+	if t, ok := l.(*time.Time); ok {
+		l = *t
+	}
+	if t, ok := r.(*time.Time); ok {
+		r = *t
+	}
+
+	switch lt := l.(type) {
+	case time.Time:
+		switch rt := r.(type) {
+		case time.Time:
+			switch op {
+			case "-":
+				return lt.Sub(rt), nil
+			case "==":
+				return lt.Equal(rt), nil
+			case "!=":
+				return !lt.Equal(rt), nil
+			case "<":
+				return lt.Before(rt), nil
+			case ">":
+				return lt.After(rt), nil
+			case "<=":
+				return !lt.After(rt), nil
+			case ">=":
+				return !lt.Before(rt), nil
+			}
+		case time.Duration:
+			switch op {
+			case "+":
+				return lt.Add(rt), nil
+			case "-":
+				return lt.Add(-rt), nil
+			}
+		}
+	case time.Duration:
+		switch rt := r.(type) {
+		case time.Time:
+			if op == "+" {
+				return rt.Add(lt), nil
+			}
+		case time.Duration:
+			switch op {
+			case "+":
+				return lt + rt, nil
+			case "-":
+				return lt - rt, nil
+			case "==", "!=", "<", ">", "<=", ">=":
+				return c.intsOperator(int(lt), int(rt), op)
+			}
+		default:
+			if op == "*" || op == "/" {
+				return c.scaleDuration(lt, r, op)
+			}
+		}
+	default:
+		if d, ok := r.(time.Duration); ok && op == "*" {
+			return c.scaleDuration(d, l, op)
+		}
+	}
+
+	return nil, fmt.Errorf("unable to operate (%s) on %T and %T", op, l, r)
+}
+
+// scaleDuration multiplies or divides a duration by a number.
+func (c *compiler) scaleDuration(d time.Duration, n interface{}, op string) (interface{}, error) {
+	nv := reflect.ValueOf(n)
+	if !isNumber(nv) {
+		return nil, fmt.Errorf("unable to operate (%s) on %T and %T", op, d, n)
+	}
+
+	res, err := c.numbersOperator(reflect.ValueOf(int64(d)), nv, op)
+	if err != nil {
+		return nil, err
+	}
+	switch v := res.(type) {
+	case int:
+		return time.Duration(v), nil
+	case uint64:
+		return time.Duration(v), nil
+	case float64:
+		return time.Duration(v), nil
+	}
+	return res, nil
+}
+
+// isNumber reports whether v holds a value of any Go integer or float kind.
+func isNumber(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// numberInt returns the integer v holds as an int, it reports false if v
+// is a float or does not fit in an int.
+func numberInt(v reflect.Value) (int, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := v.Int()
+		return int(i), i >= math.MinInt && i <= math.MaxInt
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := v.Uint()
+		return int(u), u <= math.MaxInt
+	}
+	return 0, false
+}
+
+// numberFloat returns the number v holds as a float64.
+func numberFloat(v reflect.Value) float64 {
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint())
+	}
+	return float64(v.Int())
+}
+
+// numbersOperator applies op to two numbers of any integer or float kinds.
+// A float operand makes both operands float64. Integers are operated on as
+// int, or as uint64 when one of them is an unsigned integer too large for
+// an int.
+func (c *compiler) numbersOperator(lv, rv reflect.Value, op string) (interface{}, error) {
+	if lv.Kind() == reflect.Float32 || lv.Kind() == reflect.Float64 ||
+		rv.Kind() == reflect.Float32 || rv.Kind() == reflect.Float64 {
+		return c.floatsOperator(numberFloat(lv), numberFloat(rv), op)
+	}
+
+	l, lok := numberInt(lv)
+	r, rok := numberInt(rv)
+	switch {
+	case lok && rok:
+		return c.intsOperator(l, r, op)
+	case lok && l < 0, rok && r < 0:
+		return c.signsOperator(lv, rv, op)
+	}
+
+	return c.uintsOperator(lv.Convert(reflect.TypeOf(uint64(0))).Uint(), rv.Convert(reflect.TypeOf(uint64(0))).Uint(), op)
+}
+
+// signsOperator operates on a negative number and an unsigned one too
+// large for an int. The result is computed exactly, and is an int or an
+// uint64 when it fits in one of them.
+func (c *compiler) signsOperator(lv, rv reflect.Value, op string) (interface{}, error) {
+	l, r := bigInt(lv), bigInt(rv)
+	res := new(big.Int)
+	switch op {
+	case "<":
+		return l.Cmp(r) < 0, nil
+	case "<=":
+		return l.Cmp(r) <= 0, nil
+	case ">":
+		return l.Cmp(r) > 0, nil
+	case ">=":
+		return l.Cmp(r) >= 0, nil
+	case "!=":
+		return l.Cmp(r) != 0, nil
+	case "==":
+		return l.Cmp(r) == 0, nil
+	case "+":
+		res.Add(l, r)
+	case "-":
+		res.Sub(l, r)
+	case "*":
+		res.Mul(l, r)
+	case "/", "%":
+		if r.Sign() == 0 {
+			return nil, fmt.Errorf("division by zero %v %s %v", lv, op, rv)
+		}
+		if op == "/" {
+			res.Quo(l, r)
+		} else {
+			res.Rem(l, r)
+		}
+	case "**":
+		if r.Sign() < 0 {
+			return math.Pow(numberFloat(lv), numberFloat(rv)), nil
+		}
+		// the exponent is too large for an int, only -1 does not overflow
+		if l.CmpAbs(big.NewInt(1)) > 0 {
+			return nil, fmt.Errorf("integer overflow %v %s %v", lv, op, rv)
+		}
+		res.Exp(l, r, nil)
+	default:
+		return nil, fmt.Errorf("unknown operator for integer %s", op)
+	}
+
+	switch {
+	case res.IsInt64() && res.Int64() >= math.MinInt && res.Int64() <= math.MaxInt:
+		return int(res.Int64()), nil
+	case res.IsUint64():
+		return res.Uint64(), nil
+	}
+	return nil, fmt.Errorf("integer overflow %v %s %v", lv, op, rv)
+}
+
+// bigInt returns the integer v holds as a big.Int.
+func bigInt(v reflect.Value) *big.Int {
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return new(big.Int).SetUint64(v.Uint())
+	}
+	return big.NewInt(v.Int())
+}
+
+func (c *compiler) uintsOperator(l uint64, r uint64, op string) (interface{}, error) {
+	switch op {
+	case "+":
+		res, carry := bits.Add64(l, r, 0)
+		if carry != 0 {
+			return nil, fmt.Errorf("integer overflow %v %s %v", l, op, r)
+		}
+		return res, nil
+	case "-":
+		res, borrow := bits.Sub64(l, r, 0)
+		if borrow != 0 {
+			return nil, fmt.Errorf("integer overflow %v %s %v", l, op, r)
+		}
+		return res, nil
+	case "/":
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero %v %s %v", l, op, r)
+		}
+		return l / r, nil
+	case "%":
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero %v %s %v", l, op, r)
+		}
+		return l % r, nil
+	case "*":
+		hi, res := bits.Mul64(l, r)
+		if hi != 0 {
+			return nil, fmt.Errorf("integer overflow %v %s %v", l, op, r)
+		}
+		return res, nil
+	case "**":
+		res, ok := uintPow(l, r)
+		if !ok {
+			return nil, fmt.Errorf("integer overflow %v %s %v", l, op, r)
+		}
+		return res, nil
+	case "<":
+		return l < r, nil
+	case ">":
+		return l > r, nil
+	case "!=":
+		return l != r, nil
+	case ">=":
+		return l >= r, nil
+	case "<=":
+		return l <= r, nil
+	case "==":
+		return l == r, nil
+	}
+	return nil, fmt.Errorf("unknown operator for integer %s", op)
+}
+
 func (c *compiler) intsOperator(l int, r int, op string) (interface{}, error) {
 	switch op {
 	case "+":
-		return l + r, nil
+		res := l + r
+		if (r > 0 && res < l) || (r < 0 && res > l) {
+			return nil, fmt.Errorf("integer overflow %v %s %v", l, op, r)
+		}
+		return res, nil
 	case "-":
-		return l - r, nil
+		res := l - r
+		if (r > 0 && res > l) || (r < 0 && res < l) {
+			return nil, fmt.Errorf("integer overflow %v %s %v", l, op, r)
+		}
+		return res, nil
 	case "/":
 		if r == 0 {
 			return nil, fmt.Errorf("division by zero %v %s %v", l, op, r)
 		}
+		if l == math.MinInt && r == -1 {
+			return nil, fmt.Errorf("integer overflow %v %s %v", l, op, r)
+		}
 		return l / r, nil
+	case "%":
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero %v %s %v", l, op, r)
+		}
+		return l % r, nil
 	case "*":
-		return l * r, nil
+		res, ok := intMul(l, r)
+		if !ok {
+			return nil, fmt.Errorf("integer overflow %v %s %v", l, op, r)
+		}
+		return res, nil
+	case "**":
+		if r < 0 {
+			return math.Pow(float64(l), float64(r)), nil
+		}
+		res, ok := intPow(l, r)
+		if !ok {
+			return nil, fmt.Errorf("integer overflow %v %s %v", l, op, r)
+		}
+		return res, nil
 	case "<":
 		return l < r, nil
 	case ">":
@@ -665,6 +1696,55 @@ func (c *compiler) intsOperator(l int, r int, op string) (interface{}, error) {
 	return nil, fmt.Errorf("unknown operator for integer %s", op)
 }
 
+// intMul returns l * r, it reports false if the result overflows an int.
+func intMul(l, r int) (int, bool) {
+	if l == 0 || r == 0 {
+		return 0, true
+	}
+	res := l * r
+	if res/r != l || (l == -1 && r == math.MinInt) || (r == -1 && l == math.MinInt) {
+		return 0, false
+	}
+	return res, true
+}
+
+// intPow returns l ** r by squaring for r >= 0, it reports false if the
+// result overflows an int.
+func intPow(l, r int) (int, bool) {
+	res, ok := 1, true
+	for ; r > 0 && ok; r >>= 1 {
+		if r&1 == 1 {
+			if res, ok = intMul(res, l); !ok {
+				break
+			}
+		}
+		if r > 1 {
+			l, ok = intMul(l, l)
+		}
+	}
+	return res, ok
+}
+
+// uintPow returns l ** r by squaring, it reports false if the result
+// overflows an uint64.
+func uintPow(l, r uint64) (uint64, bool) {
+	res := uint64(1)
+	for ; r > 0; r >>= 1 {
+		var hi uint64
+		if r&1 == 1 {
+			if hi, res = bits.Mul64(res, l); hi != 0 {
+				return 0, false
+			}
+		}
+		if r > 1 {
+			if hi, l = bits.Mul64(l, l); hi != 0 {
+				return 0, false
+			}
+		}
+	}
+	return res, true
+}
+
 func (c *compiler) floatsOperator(l float64, r float64, op string) (interface{}, error) {
 	switch op {
 	case "+":
@@ -676,8 +1756,15 @@ func (c *compiler) floatsOperator(l float64, r float64, op string) (interface{},
 			return nil, fmt.Errorf("division by zero %v %s %v", l, op, r)
 		}
 		return l / r, nil
+	case "%":
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero %v %s %v", l, op, r)
+		}
+		return math.Mod(l, r), nil
 	case "*":
 		return l * r, nil
+	case "**":
+		return math.Pow(l, r), nil
 	case "<":
 		return l < r, nil
 	case ">":
@@ -730,23 +1817,53 @@ func (c *compiler) evalCallExpression(node *ast.CallExpression) (interface{}, er
 	if err := c.budget().SpendFunctionCall(funcName); err != nil {
 		return nil, err
 	}
+
+	return c.callFunction(node)
+}
+
+func (c *compiler) evalFilterExpression(node *ast.FilterExpression) (interface{}, error) {
+	if err := c.budget().SpendFilter(); err != nil {
+		return nil, err
+	}
+
+	return c.callFunction(node.Call)
+}
+
+// callFunction invokes the function, method or user function referenced by
+// the call expression. Budget charges are left to the caller.
+func (c *compiler) callFunction(node *ast.CallExpression) (interface{}, error) {
 	var rv reflect.Value
 
 	if node.Callee != nil {
-		c, err := c.evalExpression(node.Callee)
+		callee, err := c.evalExpression(node.Callee)
+		if id, ok := node.Function.(*ast.Identifier); ok && id.Safe {
+			// a?.b() short-circuits to nil when a is nil or unknown
+			if _, ok := err.(*ErrUnknownIdentifier); ok || (err == nil && isNil(callee)) {
+				return nil, nil
+			}
+		}
 		if err != nil {
 			return nil, err
 		}
 
-		rc := reflect.ValueOf(c)
+		rc := reflect.ValueOf(callee)
 		mname := node.Function.String()
 		if i, ok := node.Function.(*ast.Identifier); ok {
 			mname = i.Value
 		}
 
 		rv = rc.MethodByName(mname)
+		if !rv.IsValid() && rc.Kind() == reflect.Map && rc.Type().Key().Kind() == reflect.String {
+			// functions stored in a map, such as imported macros
+			if v := rc.MapIndex(reflect.ValueOf(mname).Convert(rc.Type().Key())); v.IsValid() {
+				if ff, ok := v.Interface().(*userFunction); ok {
+					return c.evalUserFunction(node.Callee.String()+"."+mname, ff, node.Arguments)
+				}
+				rv = reflect.ValueOf(v.Interface())
+			}
+		}
 		if !rv.IsValid() && rc.Type().Kind() != reflect.Ptr {
-			ptr := reflect.New(reflect.TypeOf(c))
+			ptr := reflect.New(reflect.TypeOf(callee))
 			ptr.Elem().Set(rc)
 			rv = ptr.MethodByName(mname)
 			if !rv.IsValid() {
@@ -771,7 +1888,7 @@ func (c *compiler) evalCallExpression(node *ast.CallExpression) (interface{}, er
 		}
 
 		if ff, ok := f.(*userFunction); ok {
-			return c.evalUserFunction(ff, node.Arguments)
+			return c.evalUserFunction(node.Function.String(), ff, node.Arguments)
 		}
 
 		rv = reflect.ValueOf(f)
@@ -807,7 +1924,9 @@ func (c *compiler) evalCallExpression(node *ast.CallExpression) (interface{}, er
 			var ar reflect.Value
 			expectedT := rt.In(pos)
 			if v != nil {
-				ar = reflect.ValueOf(v)
+				if ar, err = c.argValue(v, expectedT); err != nil {
+					return nil, fmt.Errorf("invalid argument for %s at pos %d: %w", node.Function.String(), pos, err)
+				}
 			} else {
 				ar = reflect.New(expectedT).Elem()
 			}
@@ -886,7 +2005,9 @@ func (c *compiler) evalCallExpression(node *ast.CallExpression) (interface{}, er
 			var ar reflect.Value
 			expectedT := rt.In(pos)
 			if v != nil {
-				ar = reflect.ValueOf(v)
+				if ar, err = c.argValue(v, expectedT); err != nil {
+					return nil, fmt.Errorf("invalid argument for %s at pos %d: %w", node.Function.String(), pos, err)
+				}
 			} else {
 				ar = reflect.New(expectedT).Elem()
 			}
@@ -909,7 +2030,9 @@ func (c *compiler) evalCallExpression(node *ast.CallExpression) (interface{}, er
 
 			var ar reflect.Value
 			if v != nil {
-				ar = reflect.ValueOf(v)
+				if ar, err = c.argValue(v, expectedT); err != nil {
+					return nil, fmt.Errorf("invalid argument for %s at pos %d: %w", node.Function.String(), pos, err)
+				}
 			} else {
 				ar = reflect.New(expectedT)
 			}
@@ -960,121 +2083,277 @@ func (c *compiler) evalForExpression(node *ast.ForExpression) (interface{}, erro
 		return nil, err
 	}
 
+	// a loop already bound by the template or the context is left alone,
+	// only the metadata of an enclosing for loop is replaced
+	outer := octx.Value(loopKey)
+	parent, isLoop := outer.(loopInfo)
+	shadowed := outer != nil && !isLoop
+	length, known := iterLength(iter)
+	index := 0
+
+	ret := []interface{}{}
+	err = c.iterate(iter, func(k, v interface{}) (bool, error) {
+		if !shadowed {
+			c.ctx.Set(loopKey, newLoopInfo(index, length, known, parent))
+		}
+		index++
+		c.ctx.Set(node.KeyName, k)
+		if err := c.setForValue(node, v); err != nil {
+			return false, err
+		}
+
+		res, next, err := c.evalLoopBlock(node.Block)
+		if err != nil {
+			return false, err
+		}
+
+		if res != nil {
+			ret = append(ret, res)
+		}
+
+		return next, nil
+	})
+	return ret, err
+}
+
+// loopKey is the name of the loop metadata inside a for block.
+const loopKey = "loop"
+
+// loopInfo describes the current iteration of a for loop to its block.
+type loopInfo map[string]interface{}
+
+// newLoopInfo returns the loop metadata for the iteration at index.
+// The length, and so whether it is the last iteration, is only known
+// when iterating over a map, a slice or an array, it is nil otherwise.
+func newLoopInfo(index, length int, known bool, parent loopInfo) loopInfo {
+	l := loopInfo{
+		"index":  index,
+		"index1": index + 1,
+		"first":  index == 0,
+		"last":   nil,
+		"length": nil,
+		"even":   index%2 == 0,
+		"odd":    index%2 == 1,
+		"parent": nil,
+	}
+	if known {
+		l["last"] = index == length-1
+		l["length"] = length
+	}
+	if parent != nil {
+		l["parent"] = parent
+	}
+	return l
+}
+
+// iterLength returns the number of elements of iter, if it can be
+// known before iterating over it.
+func iterLength(iter interface{}) (int, bool) {
 	riter := reflect.ValueOf(iter)
 	if riter.Kind() == reflect.Ptr {
 		riter = riter.Elem()
 	}
 
-	ret := []interface{}{}
 	switch riter.Kind() {
-	case reflect.Map:
-		keys := riter.MapKeys()
-		for i := 0; i < len(keys); i++ {
-			if err := c.budget().SpendLoop(); err != nil {
-				return nil, err
-			}
-			k := keys[i]
-			v := riter.MapIndex(k)
-			c.ctx.Set(node.KeyName, k.Interface())
-			c.ctx.Set(node.ValueName, v.Interface())
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return riter.Len(), true
+	}
+	return 0, false
+}
 
-			res, err := c.evalBlockStatement(node.Block)
-			if err != nil {
+func (c *compiler) evalWhileExpression(node *ast.WhileExpression) (interface{}, error) {
+	octx := c.ctx.(*Context)
+	defer func() {
+		c.ctx = octx
+	}()
+
+	c.ctx = octx.New()
+	ret := []interface{}{}
+	for {
+		if err := c.budget().SpendCondition(); err != nil {
+			return nil, err
+		}
+
+		con, err := c.evalExpression(node.Condition)
+		if err != nil {
+			if _, ok := err.(*ErrUnknownIdentifier); !ok {
 				return nil, err
 			}
+		}
 
-			breakLoop := false
-			switch val := res.(type) {
-			case continueObject:
-				res = val.Value
-			case breakObject:
-				breakLoop = true
-				res = val.Value
-			}
+		if !c.isTruthy(con) {
+			return ret, nil
+		}
 
-			if res != nil {
-				ret = append(ret, res)
-			}
+		if err := c.budget().SpendLoop(); err != nil {
+			return nil, err
+		}
 
-			if breakLoop {
-				break
+		res, next, err := c.evalLoopBlock(node.Block)
+		if err != nil {
+			return nil, err
+		}
+
+		if res != nil {
+			ret = append(ret, res)
+		}
+
+		if !next {
+			return ret, nil
+		}
+	}
+}
+
+// evalLoopBlock evaluates the block of a loop, unwrapping a continue or
+// a break. next is false when the loop has to stop.
+func (c *compiler) evalLoopBlock(node *ast.BlockStatement) (res interface{}, next bool, err error) {
+	res, err = c.evalBlockStatement(node)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch val := res.(type) {
+	case continueObject:
+		return val.Value, true, nil
+	case breakObject:
+		return val.Value, false, nil
+	}
+	return res, true, nil
+}
+
+// iterate calls fn with the key and the value of each element of iter, a
+// map, a slice, an array or an Iterator, until fn returns false or an
+// error. Each element is charged to the loop budget.
+func (c *compiler) iterate(iter interface{}, fn func(k, v interface{}) (bool, error)) error {
+	riter := reflect.ValueOf(iter)
+	if riter.Kind() == reflect.Ptr {
+		riter = riter.Elem()
+	}
+
+	switch riter.Kind() {
+	case reflect.Map:
+		for _, k := range riter.MapKeys() {
+			if err := c.budget().SpendLoop(); err != nil {
+				return err
+			}
+			if next, err := fn(k.Interface(), riter.MapIndex(k).Interface()); err != nil || !next {
+				return err
 			}
 		}
 	case reflect.Slice, reflect.Array:
 		for i := 0; i < riter.Len(); i++ {
 			if err := c.budget().SpendLoop(); err != nil {
-				return nil, err
-			}
-			v := riter.Index(i)
-			c.ctx.Set(node.KeyName, i)
-			c.ctx.Set(node.ValueName, v.Interface())
-
-			res, err := c.evalBlockStatement(node.Block)
-			if err != nil {
-				return nil, err
-			}
-
-			breakLoop := false
-			switch val := res.(type) {
-			case continueObject:
-				res = val.Value
-			case breakObject:
-				breakLoop = true
-				res = val.Value
-			}
-
-			if res != nil {
-				ret = append(ret, res)
+				return err
 			}
-
-			if breakLoop {
-				break
+			if next, err := fn(i, riter.Index(i).Interface()); err != nil || !next {
+				return err
 			}
 		}
 	default:
 		if iter == nil {
-			return nil, nil
+			return nil
 		}
-		if it, ok := iter.(Iterator); ok {
-			i := 0
-			ii := it.Next()
-			for ii != nil {
-				if err := c.budget().SpendLoop(); err != nil {
-					return nil, err
-				}
-				c.ctx.Set(node.KeyName, i)
-				c.ctx.Set(node.ValueName, ii)
+		it, ok := iter.(Iterator)
+		if !ok {
+			return fmt.Errorf("could not iterate over %T", iter)
+		}
+		for i, ii := 0, it.Next(); ii != nil; i, ii = i+1, it.Next() {
+			if err := c.budget().SpendLoop(); err != nil {
+				return err
+			}
+			if next, err := fn(i, ii); err != nil || !next {
+				return err
+			}
+		}
+	}
+	return nil
+}
 
-				res, err := c.evalBlockStatement(node.Block)
-				if err != nil {
-					return nil, err
-				}
+func (c *compiler) evalComprehension(node *ast.Comprehension) (interface{}, error) {
+	octx := c.ctx.(*Context)
+	defer func() {
+		c.ctx = octx
+	}()
 
-				breakLoop := false
-				switch val := res.(type) {
-				case continueObject:
-					res = val.Value
-				case breakObject:
-					breakLoop = true
-					res = val.Value
-				}
+	c.ctx = octx.New()
+	iter, err := c.evalExpression(node.Iterable)
+	if err != nil {
+		return nil, err
+	}
 
-				if res != nil {
-					ret = append(ret, res)
-				}
+	keys := []interface{}{}
+	values := []interface{}{}
+	err = c.iterate(iter, func(k, v interface{}) (bool, error) {
+		c.ctx.Set(node.KeyName, k)
+		c.ctx.Set(node.ValueName, v)
 
-				if breakLoop {
-					break
+		if node.Condition != nil {
+			if err := c.budget().SpendCondition(); err != nil {
+				return false, err
+			}
+			con, err := c.evalExpression(node.Condition)
+			if err != nil {
+				if _, ok := err.(*ErrUnknownIdentifier); !ok {
+					return false, err
 				}
+			}
+			if !c.isTruthy(con) {
+				return true, nil
+			}
+		}
 
-				ii = it.Next()
-				i++
+		if node.IsHash() {
+			key, err := c.evalExpression(node.Key)
+			if err != nil {
+				return false, err
 			}
-			return ret, nil
+			keys = append(keys, key)
+		}
+
+		value, err := c.evalExpression(node.Value)
+		if err != nil {
+			return false, err
+		}
+		values = append(values, value)
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !node.IsHash() {
+		return values, nil
+	}
+	return comprehensionMap(keys, values)
+}
+
+// comprehensionMap builds the map of a hash comprehension. Its keys have the
+// type of the keys when they all have the same one, map[string]interface{}
+// for string keys as for hash literals, and interface{} otherwise.
+func comprehensionMap(keys, values []interface{}) (interface{}, error) {
+	vt := reflect.TypeOf((*interface{})(nil)).Elem()
+	kt := reflect.TypeOf("")
+	for i, k := range keys {
+		t := reflect.TypeOf(k)
+		if t == nil || !t.Comparable() {
+			return nil, fmt.Errorf("invalid hash key %v (%T)", k, k)
+		}
+		if i == 0 {
+			kt = t
+		} else if t != kt {
+			kt = vt
+		}
+	}
+
+	m := reflect.MakeMapWithSize(reflect.MapOf(kt, vt), len(keys))
+	for i, k := range keys {
+		v := reflect.Zero(vt)
+		if values[i] != nil {
+			v = reflect.ValueOf(values[i])
 		}
-		return ret, fmt.Errorf("could not iterate over %T", iter)
+		m.SetMapIndex(reflect.ValueOf(k), v)
 	}
-	return ret, nil
+	return m.Interface(), nil
 }
 
 func (c *compiler) evalBlockStatement(node *ast.BlockStatement) (interface{}, error) {
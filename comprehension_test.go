@@ -0,0 +1,65 @@
+package plush_test
+
+import (
+	"testing"
+
+	"github.com/gobuffalo/plush/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type comprehensionUser struct {
+	ID     int
+	Name   string
+	Email  string
+	Active bool
+}
+
+func Test_Comprehension(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		name     string
+		success  bool
+	}{
+		{`<%= [u.Email for u in users if u.Active] %>`, "mark@example.comjo@example.com", "array_with_condition", true},
+		{`<%= [x * 2 for x in [1, 2, 3]] %>`, "246", "array_expression", true},
+		{`<%= len([x for x in items]) %>`, "3", "array_len", true},
+		{`<%= [i for (i, x) in items] %>`, "012", "array_key_and_value", true},
+		{`<%= [x for i, x in items if i > 0] %>`, "bc", "key_and_value_without_parens", true},
+		{`<%= [x for x in items if x == "z"] %>`, "", "array_empty", true},
+		{`<%= [[y + x for y in ["1", "2"]] for x in items][1] %>`, "1b2b", "nested", true},
+		{`<% let names = {u.ID: u.Name for u in users} %><%= names[2] %>`, "paul", "hash_int_keys", true},
+		{`<% let ids = {u.Name: u.ID for u in users if u.Active} %><%= ids["jo"] %><%= len(ids) %>`, "32", "hash_string_keys", true},
+		{`<% let m = {x: i for (i, x) in items} %><%= m["c"] %>`, "2", "hash_key_and_value", true},
+		{`<%= json({u.Name: u.Active for u in users}) %>`, `{"jo":true,"mark":true,"paul":false}`, "hash_json", true},
+		{`<% let x = "outer" %><%= [x for x in items] %> <%= x %>`, "abc outer", "scoped_variables", true},
+		{`<%= [x for x in 5] %>`, "line 1: could not iterate over int", "not_iterable", false},
+		{`<%= [x for x in missing] %>`, `"missing": unknown identifier`, "unknown_iterable", false},
+		{`<%= {[x]: 1 for x in items} %>`, "invalid hash key [a] ([]interface {})", "invalid_hash_key", false},
+		{`<%= [x for a.b in items] %>`, "line 1: invalid comprehension variable a.b", "dotted_variable", false},
+		{`<%= [x for a, b, c in items] %>`, "line 1: a comprehension takes one or two variables, got 3", "too_many_variables", false},
+		{`<%= [x for x items] %>`, "expected next token to be IN", "missing_in", false},
+		{`<%= {x: 1, y: 2 for x in items} %>`, "expected next token to be ,, got FOR", "hash_with_pairs", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			ctx := plush.NewContext()
+			ctx.Set("users", []comprehensionUser{
+				{ID: 1, Name: "mark", Email: "mark@example.com", Active: true},
+				{ID: 2, Name: "paul", Email: "paul@example.com"},
+				{ID: 3, Name: "jo", Email: "jo@example.com", Active: true},
+			})
+			ctx.Set("items", []string{"a", "b", "c"})
+			s, err := plush.Render(tc.input, ctx)
+			if tc.success {
+				r.NoError(err)
+				r.Equal(tc.expected, s)
+			} else {
+				r.Error(err)
+				r.Contains(err.Error(), tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package plush_test
+
+import (
+	"testing"
+
+	"github.com/gobuffalo/plush/v5"
+	"github.com/gobuffalo/plush/v5/helpers/meta"
+	"github.com/gobuffalo/plush/v5/templatecache/inmemory"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Render_WithDelims(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		name     string
+	}{
+		{`<p>{{= name }}</p>`, `<p>mark</p>`, "expression"},
+		{`{{ let x = 2 }}{{= x * 2 }}`, `4`, "statement"},
+		{`a{{# a comment }}b`, `ab`, "comment"},
+		{`{{= if (name == "mark") { }}yes{{ } else { }}no{{ } }}`, `yes`, "if"},
+		{`{{= for (n) in [1, 2] { }}<%= n %>{{= n }} {{ } }}`, `<%= n %>1 <%= n %>2 `, "other_delims_are_html"},
+		{`{{= {"a": 1}["a"] }}`, `1`, "hash"},
+		{"a\n{{=- name -}}\nb", `amarkb`, "trim"},
+		{`{{raw}}{{= name }}{{endraw}}`, `{{= name }}`, "raw"},
+		{`a \{{= name }}`, `a {{= name }}`, "escaped"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			ctx := plush.NewContext()
+			ctx.Set("name", "mark")
+			s, err := plush.RenderWithOptions(tc.input, ctx, plush.WithDelims("{{", "}}"))
+			r.NoError(err)
+			r.Equal(tc.expected, s)
+		})
+	}
+}
+
+func Test_NewTemplate_WithDelims(t *testing.T) {
+	r := require.New(t)
+	tmpl, err := plush.NewTemplate(`[[= 1 + 1 ]]<%= x %>`, plush.WithDelims("[[", "]]"))
+	r.NoError(err)
+
+	s, _, err := tmpl.Exec(plush.NewContext())
+	r.NoError(err)
+	r.Equal(`2<%= x %>`, s)
+
+	s, _, err = tmpl.Clone().Exec(plush.NewContext())
+	r.NoError(err)
+	r.Equal(`2<%= x %>`, s)
+}
+
+func Test_NewTemplate_WithDelims_Empty(t *testing.T) {
+	r := require.New(t)
+	tmpl, err := plush.NewTemplate(`<%= 1 %>`, plush.WithDelims("", ""))
+	r.NoError(err)
+
+	s, _, err := tmpl.Exec(plush.NewContext())
+	r.NoError(err)
+	r.Equal(`1`, s)
+}
+
+func Test_Render_WithDelims_Cached(t *testing.T) {
+	r := require.New(t)
+	plush.UseTemplateCache(t, inmemory.NewMemoryCache())
+	input := `<%= 1 %>{{= 2 }}`
+
+	ctx := plush.NewContext()
+	ctx.Set(meta.TemplateFileKey, "delims_cached.plush")
+	s, err := plush.Render(input, ctx)
+	r.NoError(err)
+	r.Equal(`1{{= 2 }}`, s)
+
+	s, err = plush.RenderWithOptions(input, ctx, plush.WithDelims("{{", "}}"))
+	r.NoError(err)
+	r.Equal(`<%= 1 %>2`, s)
+
+	s, err = plush.Render(input, ctx)
+	r.NoError(err)
+	r.Equal(`1{{= 2 }}`, s)
+}
+
+func Test_Render_WithDelims_Holes(t *testing.T) {
+	r := require.New(t)
+	ctx := plush.NewContext()
+	plush.UseTemplateCache(t, inmemory.NewMemoryCache())
+	ctx.Set(meta.TemplateFileKey, "delims.plush")
+	ctx.Set("names", []string{"a", "b"})
+
+	input := `{{= len(names) }}|{{H for (n) in names { }}{{= n }}{{ } }}|{{H "done" }}`
+	s, err := plush.RenderWithOptions(input, ctx, plush.WithDelims("{{", "}}"))
+	r.NoError(err)
+	r.Equal(`2|ab|done`, s)
+}
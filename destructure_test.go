@@ -0,0 +1,68 @@
+package plush_test
+
+import (
+	"testing"
+
+	"github.com/gobuffalo/plush/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type destructureUser struct {
+	Name  string
+	Email string
+	age   int
+}
+
+func Test_Destructure(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		name     string
+		success  bool
+	}{
+		{`<% let [first, second] = pair %><%= first %>-<%= second %>`, "a-b", "let_array", true},
+		{`<% let [first] = pair %><%= first %>`, "a", "let_array_fewer_names", true},
+		{`<% let [x, y] = [1, "z"] %><%= x %><%= y %>`, "1z", "let_array_literal", true},
+		{`<% let {Name, Email} = user %><%= Name %> <%= Email %>`, "mark mark@example.com", "let_struct", true},
+		{`<% let {Name} = userPtr %><%= Name %>`, "paul", "let_struct_pointer", true},
+		{`<% let {theme, size} = settings %><%= theme %><%= size %>`, "dark3", "let_map", true},
+		{`<% let {a} = {"a": 1} %><%= a %>`, "1", "let_hash_literal", true},
+		{`<%= for (i, {Name, Email}) in users { %><%= i %>:<%= Name %>/<%= Email %> <% } %>`, "0:mark/mark@example.com 1:paul/paul@example.com ", "for_struct", true},
+		{`<%= for ({Name}) in users { %><%= Name %><% } %>`, "markpaul", "for_value_only", true},
+		{`<%= for (i, [a, b]) in pairs { %><%= a + b %><% } %>`, "37", "for_array", true},
+		{`<%= for (k, [a]) in {"x": [1]} { %><%= k %><%= a %><% } %>`, "x1", "for_map_iterable", true},
+		{`<% let [a, b, c] = pair %>`, "can not destructure 'pair' into [a, b, c], it has 2 elements", "too_few_elements", false},
+		{`<% let [a] = user %>`, "can not destructure 'user' (plush_test.destructureUser) into [a]", "array_of_struct", false},
+		{`<% let {Phone} = user %>`, "'user' does not have a field named 'Phone' ({Phone})", "missing_field", false},
+		{`<% let {age} = user %>`, "'user' does not have a field named 'age' ({age})", "unexported_field", false},
+		{`<% let {color} = settings %>`, "'settings' does not have a key named 'color' ({color})", "missing_key", false},
+		{`<% let {a} = 1 %>`, "can not destructure '1' (int) into {a}", "scalar", false},
+		{"\n<%= for (i, {Phone}) in users { %><% } %>", "line 2: 'users element' does not have a field named 'Phone' ({Phone})", "for_missing_field", false},
+		{`<%= for ({Name}, i) in users { %><% } %>`, "a destructuring pattern must be the value of the for loop", "for_pattern_not_last", false},
+		{`<% let {a.b} = user %>`, "can not destructure into a.b", "dotted_name", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			ctx := plush.NewContext()
+			ctx.Set("pair", []string{"a", "b"})
+			ctx.Set("user", destructureUser{Name: "mark", Email: "mark@example.com"})
+			ctx.Set("userPtr", &destructureUser{Name: "paul", Email: "paul@example.com"})
+			ctx.Set("users", []destructureUser{
+				{Name: "mark", Email: "mark@example.com"},
+				{Name: "paul", Email: "paul@example.com"},
+			})
+			ctx.Set("pairs", [][]int{{1, 2}, {3, 4}})
+			ctx.Set("settings", map[string]interface{}{"theme": "dark", "size": 3})
+			s, err := plush.Render(tc.input, ctx)
+			if tc.success {
+				r.NoError(err)
+				r.Equal(tc.expected, s)
+			} else {
+				r.Error(err)
+				r.Contains(err.Error(), tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package plush_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/plush/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type equalityOption struct {
+	ID    int
+	Label string
+	note  string
+}
+
+// equalityCents is equal to another amount with the same whole units.
+type equalityCents struct {
+	cents int
+}
+
+func (c equalityCents) Equal(other equalityCents) bool {
+	return c.cents/100 == other.cents/100
+}
+
+// equalityNode can refer back to itself through its parent.
+type equalityNode struct {
+	Name   string
+	Parent *equalityNode
+}
+
+// equalityID prints as text, like a uuid.
+type equalityID [2]byte
+
+func (id equalityID) String() string {
+	return fmt.Sprintf("%x", id[:])
+}
+
+// equalityName prints as its name.
+type equalityName struct {
+	name string
+}
+
+func (n equalityName) String() string {
+	return n.name
+}
+
+func cyclicNode(name string) *equalityNode {
+	n := &equalityNode{Name: name}
+	n.Parent = n
+	return n
+}
+
+func Test_Equality(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		input    string
+		expected string
+		name     string
+	}{
+		{`<%= a == b %>`, "true", "struct_equal"},
+		{`<%= a == c %>`, "false", "struct_not_equal"},
+		{`<%= a != c %>`, "true", "struct_different"},
+		{`<%= a == hidden %>`, "false", "struct_unexported_field"},
+		{`<%= ptr == a %>`, "true", "pointer_and_value"},
+		{`<%= ptr == ptr %>`, "true", "same_pointer"},
+		{`<%= a == "one" %>`, "false", "struct_and_string"},
+		{`<%= a == nil %>`, "false", "struct_and_nil"},
+		{`<%= tags == ["a", "b"] %>`, "true", "slice_and_literal"},
+		{`<%= tags == ["b", "a"] %>`, "false", "slice_order"},
+		{`<%= tags != ["a"] %>`, "true", "slice_length"},
+		{`<%= ids == [1, 2] %>`, "true", "int64_slice_and_literal"},
+		{`<%= counts == {"x": 1} %>`, "true", "map_and_literal"},
+		{`<%= counts == {"x": 2} %>`, "false", "map_value"},
+		{`<%= counts == {"y": 1} %>`, "false", "map_key"},
+		{`<%= intKeys == int64Keys %>`, "true", "map_numeric_keys"},
+		{`<%= floatKeys == intKeys %>`, "false", "map_float_and_int_keys"},
+		{`<%= negativeKeys == uintKeys %>`, "false", "map_negative_and_uint_keys"},
+		{`<%= [a, b] == [b, a] %>`, "true", "nested"},
+		{`<%= price == same %>`, "true", "equal_method"},
+		{`<%= price == other %>`, "false", "equal_method_not_equal"},
+		{`<%= now == nowElsewhere %>`, "true", "time_equal_method"},
+		{`<%= root == sameRoot %>`, "true", "cycle_equal"},
+		{`<%= root == otherRoot %>`, "false", "cycle_not_equal"},
+		{`<%= "abcd" == id %>`, "true", "string_and_stringer_array"},
+		{`<%= "abce" != id %>`, "true", "string_and_different_stringer_array"},
+		{`<%= "x" == name %>`, "true", "string_and_stringer_struct"},
+		{`<%= "y" == name %>`, "false", "string_and_different_stringer_struct"},
+		{`<%= for (o) in options { %><option<%= if (o == selected) { %> selected<% } %>><%= o.Label %></option><% } %>`, "<option selected>one</option><option>two</option>", "select_list"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			ctx := plush.NewContext()
+			ctx.Set("a", equalityOption{ID: 1, Label: "one"})
+			ctx.Set("b", equalityOption{ID: 1, Label: "one"})
+			ctx.Set("c", equalityOption{ID: 2, Label: "two"})
+			ctx.Set("hidden", equalityOption{ID: 1, Label: "one", note: "hidden"})
+			ctx.Set("ptr", &equalityOption{ID: 1, Label: "one"})
+			ctx.Set("options", []equalityOption{{ID: 1, Label: "one"}, {ID: 2, Label: "two"}})
+			ctx.Set("selected", equalityOption{ID: 1, Label: "one"})
+			ctx.Set("tags", []string{"a", "b"})
+			ctx.Set("ids", []int64{1, 2})
+			ctx.Set("counts", map[string]int{"x": 1})
+			ctx.Set("intKeys", map[int]string{1: "a"})
+			ctx.Set("int64Keys", map[int64]string{1: "a"})
+			ctx.Set("floatKeys", map[float64]string{1.5: "a"})
+			ctx.Set("negativeKeys", map[int]string{-1: "a"})
+			ctx.Set("uintKeys", map[uint64]string{math.MaxUint64: "a"})
+			ctx.Set("price", equalityCents{cents: 1050})
+			ctx.Set("same", equalityCents{cents: 1099})
+			ctx.Set("other", equalityCents{cents: 1150})
+			ctx.Set("now", now)
+			ctx.Set("nowElsewhere", now.In(time.FixedZone("elsewhere", 3600)))
+			ctx.Set("id", equalityID{0xab, 0xcd})
+			ctx.Set("name", equalityName{name: "x"})
+			ctx.Set("root", cyclicNode("root"))
+			ctx.Set("sameRoot", cyclicNode("root"))
+			ctx.Set("otherRoot", cyclicNode("other"))
+
+			s, err := plush.Render(tc.input, ctx)
+			r.NoError(err)
+			r.Equal(tc.expected, s)
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package plush
+
+import "testing"
+
+// UseTemplateCache sets up ts as the template cache until the test ends,
+// the cache in use before is then restored.
+func UseTemplateCache(t testing.TB, ts TemplateCache) {
+	enabled, backend := cacheEnabled, templateCacheBackend
+	t.Cleanup(func() {
+		cacheEnabled, templateCacheBackend = enabled, backend
+	})
+	PlushCacheSetup(ts)
+}
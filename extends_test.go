@@ -0,0 +1,59 @@
+package plush_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gobuffalo/plush/v5"
+	"github.com/stretchr/testify/require"
+)
+
+var extendsTemplates = map[string]string{
+	"base.html":  `<title><%= block("title") { %>Site<% } %></title><main><%= block("main") { %>empty<% } %></main>`,
+	"page.html":  `<% extends("base.html") %><%= block("title") { %>Page - <%= super() %><% } %>`,
+	"empty.html": `<% extends("base.html") %>`,
+}
+
+func Test_Extends(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		name     string
+		success  bool
+	}{
+		{`<% extends("base.html") %>ignored<%= block("main") { %>hi <%= name %><% } %>`, `<title>Site</title><main>hi mark</main>`, "override", true},
+		{`<% extends("base.html") %><%= block("title") { %><%= super() %>!<% } %>`, `<title>Site!</title><main>empty</main>`, "super", true},
+		{`<% extends("empty.html") %>`, `<title>Site</title><main>empty</main>`, "defaults", true},
+		{`<% extends("page.html") %><%= block("title") { %>Home | <%= super() %><% } %>`, `<title>Home | Page - Site</title><main>empty</main>`, "multi_level", true},
+		{`<% extends("page.html") %><%= block("main") { %>x<% } %>`, `<title>Page - Site</title><main>x</main>`, "multi_level_other_block", true},
+		{`<% let name = "paul" %><% extends("base.html") %><%= block("main") { %><%= name %><% } %>`, `<title>Site</title><main>paul</main>`, "child_variables", true},
+		{`a<%= block("main") { %>b<% } %>c`, `abc`, "without_extends", true},
+		{`a<%= block("main") %>c`, `ac`, "without_default", true},
+		{`<% extends("missing.html") %>`, `could not find missing.html`, "missing", false},
+		{`<% extends("base.html") %><% extends("base.html") %>`, `template already extends "base.html"`, "twice", false},
+		{`<%= block("main") { %><%= super() %><% } %>`, `block "main" does not override any block`, "super_without_parent", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			ctx := plush.NewContext()
+			ctx.Set("name", "mark")
+			ctx.Set("partialFeeder", func(name string) (string, error) {
+				s, ok := extendsTemplates[name]
+				if !ok {
+					return "", fmt.Errorf("could not find %s", name)
+				}
+				return s, nil
+			})
+			s, err := plush.Render(tc.input, ctx)
+			if tc.success {
+				r.NoError(err)
+				r.Equal(tc.expected, s)
+			} else {
+				r.Error(err)
+				r.Contains(err.Error(), tc.expected)
+			}
+		})
+	}
+}
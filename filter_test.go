@@ -0,0 +1,59 @@
+package plush_test
+
+import (
+	"testing"
+
+	"github.com/gobuffalo/plush/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Filter_Expression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		name     string
+	}{
+		{`<%= "hi" | upcase %>`, "HI", "single"},
+		{`<%= "hello world" | upcase | truncate({size: 8}) %>`, "HELLO...", "chained_with_args"},
+		{`<% let x = "ab" | upcase %><%= x %>`, "AB", "let"},
+		{`<%= "a" | upcase == "A" %>`, "true", "binds_tighter_than_comparison"},
+		{`<%= "x" + "y" | upcase %>`, "xY", "binds_tighter_than_sum"},
+		{`<%= ["a", "b"] | len %>`, "2", "array"},
+		{`<% let join = fn(a, b) { return a + b } %><%= "a" | join("b") %>`, "ab", "user_function"},
+		{`<%= if ("a" | upcase == "A") { %>yes<% } %>`, "yes", "if_condition"},
+		{`<%= "a" || "b" %>`, "true", "logical_or_unchanged"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			s, err := plush.Render(tc.input, plush.NewContext())
+			r.NoError(err)
+			r.Equal(tc.expected, s)
+		})
+	}
+}
+
+func Test_Filter_Expression_Method(t *testing.T) {
+	r := require.New(t)
+	ctx := plush.NewContext()
+	ctx.Set("f", formatter{prefix: "> "})
+	s, err := plush.Render(`<%= "quote" | f.Format %>`, ctx)
+	r.NoError(err)
+	r.Equal("&gt; quote", s)
+}
+
+func Test_Filter_Expression_Invalid(t *testing.T) {
+	r := require.New(t)
+	_, err := plush.Render(`<%= "a" | 1 %>`, plush.NewContext())
+	r.Error(err)
+	r.Contains(err.Error(), "expected next token to be IDENT")
+}
+
+type formatter struct {
+	prefix string
+}
+
+func (f formatter) Format(s string) string {
+	return f.prefix + s
+}
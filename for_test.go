@@ -221,6 +221,14 @@ func Test_Render_For_Func_Range(t *testing.T) {
 	r.Equal("345", s)
 }
 
+func Test_Render_For_Func_Range_Step(t *testing.T) {
+	r := require.New(t)
+	input := `<%= for (v) in range(10, 0, -2) { %><%=v%> <% } %>|<%= for (v) in range(1, 10, 4) { %><%=v%> <% } %>`
+	s, err := plush.Render(input, plush.NewContext())
+	r.NoError(err)
+	r.Equal("10 8 6 4 2 0 |1 5 9 ", s)
+}
+
 func Test_Render_For_Func_Between(t *testing.T) {
 	r := require.New(t)
 	input := `<%= for (v) in between(3,6) { %><%=v%><% } %>`
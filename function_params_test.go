@@ -0,0 +1,60 @@
+package plush_test
+
+import (
+	"testing"
+
+	"github.com/gobuffalo/plush/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Function_Parameters(t *testing.T) {
+	greet := `<% let greet = fn(name, greeting = "Hi", ...rest) { %><%= greeting %> <%= name %><%= for (r) in rest { %><%= r %><% } %><% } %>`
+	tests := []struct {
+		input    string
+		expected string
+		name     string
+	}{
+		{greet + `<%= greet("mark") %>`, "Hi mark", "default"},
+		{greet + `<%= greet("mark", "Hello") %>`, "Hello mark", "override_default"},
+		{greet + `<%= greet("mark", "Hello", "!", "?") %>`, "Hello mark!?", "rest"},
+		{`<% let f = fn(a, b = a + 1) { return b } %><%= f(1) %>`, "2", "default_uses_earlier_parameter"},
+		{`<% let n = 5 %><% let f = fn(a = n) { return a } %><%= f() %>`, "5", "default_uses_closure"},
+		{`<% let f = fn(...all) { return len(all) } %><%= f() %>|<%= f(1, 2, 3) %>`, "0|3", "only_rest"},
+		{`<% let f = fn(a, ...rest) { return rest } %><%= for (v) in f(1, "x", "y") { %><%= v %><% } %>`, "xy", "rest_is_a_slice"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			s, err := plush.Render(tc.input, plush.NewContext())
+			r.NoError(err)
+			r.Equal(tc.expected, s)
+		})
+	}
+}
+
+func Test_Function_Parameters_Arity(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+		name  string
+	}{
+		{"<% let add = fn(a, b) { return a + b } %>\n<%= add(1) %>", "line 2: add: too few arguments (1 for 2)", "too_few"},
+		{"<% let add = fn(a, b) { return a + b } %>\n\n<%= add(1, 2, 3) %>", "line 3: add: too many arguments (3 for 2)", "too_many"},
+		{`<% let f = fn(a, b = 1) { return a } %><%= f() %>`, "f: too few arguments (0 for 1 to 2)", "too_few_with_default"},
+		{`<% let f = fn(a, b = 1) { return a } %><%= f(1, 2, 3) %>`, "f: too many arguments (3 for 1 to 2)", "too_many_with_default"},
+		{`<% let f = fn(a, ...rest) { return a } %><%= f() %>`, "f: too few arguments (0 for at least 1)", "too_few_with_rest"},
+		{`<% let m = {"f": fn(a) { return a }} %><%= m.f() %>`, "m.f: too few arguments (0 for 1)", "map_member"},
+		{`<% let f = fn(a = 1, b) { return a } %>`, "line 1: parameter b without a default follows a parameter with a default", "required_after_default"},
+		{`<% let f = fn(...rest, a) { return a } %>`, "line 1: ...rest must be the last parameter", "rest_not_last"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			_, err := plush.Render(tc.input, plush.NewContext())
+			r.Error(err)
+			r.Contains(err.Error(), tc.err)
+		})
+	}
+}
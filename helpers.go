@@ -12,4 +12,7 @@ var Helpers = helpers.NewMap(map[string]interface{}{})
 func init() {
 	Helpers.AddMany(helpers.Base)
 	Helpers.Add("partial", PartialHelper)
+	Helpers.Add("extends", ExtendsHelper)
+	Helpers.Add("block", BlockHelper)
+	Helpers.Add("import", ImportHelper)
 }
@@ -12,6 +12,7 @@ import (
 	"github.com/gobuffalo/plush/v5/helpers/meta"
 	"github.com/gobuffalo/plush/v5/helpers/paths"
 	"github.com/gobuffalo/plush/v5/helpers/text"
+	"github.com/gobuffalo/plush/v5/helpers/times"
 )
 
 var Content = content.New()
@@ -24,6 +25,7 @@ var Iterators = iterators.New()
 var Meta = meta.New()
 var Paths = paths.New()
 var Text = text.New()
+var Times = times.New()
 
 var Base = hctx.Merge(
 	Content,
@@ -36,4 +38,5 @@ var Base = hctx.Merge(
 	Meta,
 	Paths,
 	Text,
+	Times,
 )
@@ -1,8 +1,19 @@
 package iterators
 
+import "math"
+
 // Range creates an Iterator that will
 // iterate numbers from a to b, including b.
-func Range(a, b int) Iterator {
+// An optional step sets the distance between numbers, a negative
+// step counts down and a step of 0 iterates nothing.
+//
+//	Range(1,5)     // 1,2,3,4,5
+//	Range(0,10,3)  // 0,3,6,9
+//	Range(10,0,-2) // 10,8,6,4,2,0
+func Range(a, b int, step ...int) Iterator {
+	if len(step) > 0 {
+		return &stepper{pos: a, end: b, step: step[0]}
+	}
 	return &ranger{pos: a - 1, end: b}
 }
 
@@ -19,3 +30,23 @@ func (r *ranger) Next() interface{} {
 	}
 	return nil
 }
+
+type stepper struct {
+	pos  int
+	end  int
+	step int
+}
+
+// Next returns the next number in the stepped Range or nil
+func (s *stepper) Next() interface{} {
+	if s.step == 0 || (s.step > 0 && s.pos > s.end) || (s.step < 0 && s.pos < s.end) {
+		return nil
+	}
+	v := s.pos
+	if (s.step > 0 && s.pos > math.MaxInt-s.step) || (s.step < 0 && s.pos < math.MinInt-s.step) {
+		// the next number would overflow, so this one is the last
+		s.step = 0
+	}
+	s.pos += s.step
+	return v
+}
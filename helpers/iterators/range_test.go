@@ -0,0 +1,39 @@
+package iterators
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func collect(it Iterator) []interface{} {
+	res := []interface{}{}
+	for v := it.Next(); v != nil; v = it.Next() {
+		res = append(res, v)
+	}
+	return res
+}
+
+func Test_Range(t *testing.T) {
+	r := require.New(t)
+	r.Equal([]interface{}{1, 2, 3}, collect(Range(1, 3)))
+	r.Empty(collect(Range(3, 1)))
+}
+
+func Test_Range_Step(t *testing.T) {
+	r := require.New(t)
+	r.Equal([]interface{}{0, 3, 6, 9}, collect(Range(0, 10, 3)))
+	r.Equal([]interface{}{0, 2, 4}, collect(Range(0, 4, 2)))
+	r.Equal([]interface{}{10, 8, 6, 4, 2, 0}, collect(Range(10, 0, -2)))
+	r.Equal([]interface{}{5}, collect(Range(5, 5, -1)))
+	r.Empty(collect(Range(0, 10, -1)))
+	r.Empty(collect(Range(10, 0, 1)))
+	r.Empty(collect(Range(0, 10, 0)))
+}
+
+func Test_Range_Step_Overflow(t *testing.T) {
+	r := require.New(t)
+	r.Equal([]interface{}{math.MaxInt - 1}, collect(Range(math.MaxInt-1, math.MaxInt, 2)))
+	r.Equal([]interface{}{math.MinInt + 1}, collect(Range(math.MinInt+1, math.MinInt, -2)))
+}
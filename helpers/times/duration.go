@@ -0,0 +1,13 @@
+package times
+
+import (
+	"time"
+)
+
+// Duration parses s as a time.Duration, such as "2h" or "1h30m", see
+// time.ParseDuration for its format.
+//
+//	<%= if (post.ExpiresAt - today < duration("24h")) { %>expires soon<% } %>
+func Duration(s string) (time.Duration, error) {
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,19 @@
+package times
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Duration(t *testing.T) {
+	r := require.New(t)
+
+	d, err := Duration("1h30m")
+	r.NoError(err)
+	r.Equal(90*time.Minute, d)
+
+	_, err = Duration("two hours")
+	r.Error(err)
+}
@@ -0,0 +1,17 @@
+package times
+
+import (
+	"github.com/gobuffalo/plush/v5/helpers/hctx"
+)
+
+// Keys to be used in templates for the functions in this package.
+const (
+	DurationKey = "duration"
+)
+
+// New returns a map of the helpers within this package.
+func New() hctx.Map {
+	return hctx.Map{
+		DurationKey: Duration,
+	}
+}
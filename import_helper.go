@@ -0,0 +1,71 @@
+package plush
+
+import (
+	"fmt"
+
+	"github.com/gobuffalo/plush/v5/ast"
+)
+
+// ImportHelper loads the named template through the partial feeder and
+// returns the user functions it declares, by name. Only the top-level `let`
+// statements of the template are evaluated, its output is discarded.
+/*
+	<% import("macros/forms.plush.html") as forms %>
+	<%= forms.input("email") %>
+*/
+func ImportHelper(name string, help HelperContext) (map[string]interface{}, error) {
+	if help.Context == nil || help.compiler == nil {
+		return nil, fmt.Errorf("invalid context. abort")
+	}
+
+	if ctx, ok := help.Context.(*Context); ok {
+		if err := ctx.Budget().SpendSubRender(); err != nil {
+			return nil, err
+		}
+	}
+
+	pf, ok := help.Value("partialFeeder").(func(string) (string, error))
+	if !ok {
+		return nil, fmt.Errorf("could not find partial feeder from helpers")
+	}
+
+	input, err := pf(name)
+	if err != nil {
+		return nil, err
+	}
+
+	d := help.compiler.delims
+	t, err := NewTemplate(input, WithDelims(d.Left, d.Right), WithTrimBlocks(help.compiler.trimBlocks))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	c := &compiler{
+		ctx:        help.New(),
+		program:    t.Program,
+		delims:     t.delims,
+		trimBlocks: t.trimBlocks,
+	}
+
+	macros := map[string]interface{}{}
+	for _, stmt := range t.Program.Statements {
+		let, ok := stmt.(*ast.LetStatement)
+		if !ok {
+			continue
+		}
+		if _, err := c.evalLetStatement(let); err != nil {
+			return nil, fmt.Errorf("%s: line %d: %w", name, let.T().LineNumber, err)
+		}
+		names := []*ast.Identifier{let.Name}
+		if let.Pattern != nil {
+			names = let.Pattern.Names
+		}
+		for _, n := range names {
+			if f, ok := c.ctx.Value(n.Value).(*userFunction); ok {
+				macros[n.Value] = f
+			}
+		}
+	}
+
+	return macros, nil
+}
@@ -0,0 +1,62 @@
+package plush_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gobuffalo/plush/v5"
+	"github.com/stretchr/testify/require"
+)
+
+var importTemplates = map[string]string{
+	"macros/forms.plush.html": `<p>not rendered</p>
+<% let input = fn(name) { %><input name="<%= name %>"><% } %>
+<% let label = fn(text) { return "<" + text + ">" } %>
+<% let size = 3 %>`,
+	"macros/broken.plush.html": `<% let x = missing() %>`,
+	"macros/syntax.plush.html": `<% let = 1 %>`,
+}
+
+func Test_Import(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		name     string
+		success  bool
+	}{
+		{`<% import("macros/forms.plush.html") as forms %><%= forms.input("email") %>`, `<input name="email">`, "as", true},
+		{`<% let f = import("macros/forms.plush.html") %><%= f.label("a") %>`, `&lt;a&gt;`, "let", true},
+		{`<% import("macros/forms.plush.html") as forms %><%= len(forms) %>`, `2`, "only_functions", true},
+		{`<% import("macros/forms.plush.html") as forms %><%= for (k, v) in ["a", "b"] { %><%= forms.input(v) %><% } %>`, `<input name="a"><input name="b">`, "in_loop", true},
+		{`<% import("macros/forms.plush.html") as forms %><%= forms["label"]("b") %>`, `&lt;b&gt;`, "index", true},
+		{`<% let as = 2 %><%= as %>`, `2`, "as_is_a_name", true},
+		{`<% let as = "f" %><% import("macros/forms.plush.html") as as %><%= as.label("c") %>`, `&lt;c&gt;`, "as_named_as", true},
+		{`<% import("macros/missing.plush.html") as forms %>`, `could not find macros/missing.plush.html`, "missing", false},
+		{`<% import("macros/broken.plush.html") as forms %>`, `macros/broken.plush.html: line 1: "missing": unknown identifier`, "evaluation", false},
+		{`<% import("macros/syntax.plush.html") as forms %>`, `macros/syntax.plush.html: line 1`, "syntax", false},
+		{`<% import("macros/forms.plush.html") as forms %><%= forms.nope("a") %>`, `does not have a method named 'nope'`, "unknown_macro", false},
+		{`<% len("a") as x %>`, `line 1: as can only follow import(...)`, "as_without_import", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			ctx := plush.NewContext()
+			ctx.Set("partialFeeder", func(name string) (string, error) {
+				s, ok := importTemplates[name]
+				if !ok {
+					return "", fmt.Errorf("could not find %s", name)
+				}
+				return s, nil
+			})
+			s, err := plush.Render(tc.input, ctx)
+			if tc.success {
+				r.NoError(err)
+				r.Equal(tc.expected, s)
+			} else {
+				r.Error(err)
+				r.Contains(err.Error(), tc.expected)
+			}
+		})
+	}
+}
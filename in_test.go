@@ -0,0 +1,60 @@
+package plush_test
+
+import (
+	"testing"
+
+	"github.com/gobuffalo/plush/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_In_Operator(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		name     string
+		success  bool
+	}{
+		{`<%= "admin" in roles %>`, "true", "slice", true},
+		{`<%= "root" in roles %>`, "false", "slice_missing", true},
+		{`<%= "root" not in roles %>`, "true", "not_in", true},
+		{`<%= !("admin" in roles) %>`, "false", "bang", true},
+		{`<%= 2 in [1, 2, "three"] %>`, "true", "mixed_array", true},
+		{`<%= true in [1, "two"] %>`, "false", "mixed_array_missing", true},
+		{`<%= 2 in ids %>`, "true", "array", true},
+		{`<%= "key" in settings %>`, "true", "map_key", true},
+		{`<%= "value" in settings %>`, "false", "map_value_is_not_key", true},
+		{`<%= "foo" in "foobar" %>`, "true", "substring", true},
+		{`<%= "baz" not in "foobar" %>`, "true", "not_substring", true},
+		{`<%= "a" in nothing %>`, "false", "nil_slice", true},
+		{`<%= "a" in nil %>`, "false", "nil_container", true},
+		{`<%= "a" + "dmin" in roles == true %>`, "true", "precedence", true},
+		{`<%= if ("admin" in roles && 3 not in [1, 2]) { %>yes<% } %>`, "yes", "if_condition", true},
+		{`<%= for (i, r) in roles { %><%= r %><% } %>`, "adminuser", "for_loop_unchanged", true},
+		{`<%= 1 in settings %>`, "cannot use 1 (int constant) as string value in map index", "map_key_type", false},
+		{`<%= 1 in "foo" %>`, "cannot check int in string", "string_non_string", false},
+		{`<%= 1 in 2 %>`, "cannot check membership in int", "non_container", false},
+		{`<% let not = 1 %><%= not %>`, "1", "not_as_name", true},
+		{`<% let not = [1] %><%= 1 in not %><%= 1 not in not %>`, "truefalse", "not_as_container", true},
+		{`<%= 1 not 2 %>`, `"not": unknown identifier`, "not_without_in", false},
+		{`<%= (r) in roles { %><%= r %><% } %>`, "missing for keyword", "missing_for", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			ctx := plush.NewContext()
+			ctx.Set("roles", []string{"admin", "user"})
+			ctx.Set("ids", [3]int{1, 2, 3})
+			ctx.Set("settings", map[string]string{"key": "value"})
+			ctx.Set("nothing", []string(nil))
+			s, err := plush.Render(tc.input, ctx)
+			if tc.success {
+				r.NoError(err)
+				r.Equal(tc.expected, s)
+			} else {
+				r.Error(err)
+				r.Contains(err.Error(), tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package plush
+
+import (
+	"fmt"
+	"html/template"
+	"time"
+)
+
+// templateBlocksKey is a unique key used in the helper context to hand the
+// blocks overridden by a child template over to the template it extends.
+var templateBlocksKey = "__plush_internal_template_blocks_" + fmt.Sprintf("%d", time.Now().UnixNano()) + "__"
+
+// templateBlock renders a block overriding another one, super renders the
+// block it overrides.
+type templateBlock func(super func() (template.HTML, error)) (template.HTML, error)
+
+// templateBlocks holds the overrides of every block by name, from the most
+// derived template to the least derived one.
+type templateBlocks map[string][]templateBlock
+
+// ExtendsHelper makes the current template extend the named template. The
+// output of the current template is discarded, its blocks override the
+// blocks of the same name in the extended template which is rendered,
+// through the partial feeder, in its place. It must be called before any
+// block of the template.
+/*
+	<% extends("layouts/base.plush.html") %>
+	<%= block("title") { %>Home - <%= super() %><% } %>
+*/
+func ExtendsHelper(name string, help HelperContext) error {
+	if help.compiler == nil {
+		return fmt.Errorf("invalid context. abort")
+	}
+	c := help.compiler
+	if c.extends != "" {
+		return fmt.Errorf("template already extends %q", c.extends)
+	}
+	c.extends = name
+	c.blocks, _ = help.Value(templateBlocksKey).(templateBlocks)
+	if c.blocks == nil {
+		c.blocks = templateBlocks{}
+	}
+	return nil
+}
+
+// BlockHelper defines a named region of a template, its block is the
+// default content of the region. Templates extending it can override the
+// region and render its default content with super().
+/*
+	<title><%= block("title") { %>My App<% } %></title>
+*/
+func BlockHelper(name string, help HelperContext) (template.HTML, error) {
+	if help.compiler == nil {
+		return "", fmt.Errorf("invalid context. abort")
+	}
+	c := help.compiler
+
+	render := func(help HelperContext, super func() (template.HTML, error)) (template.HTML, error) {
+		if !help.HasBlock() {
+			return "", nil
+		}
+		hc := help.New()
+		hc.Set("super", super)
+		s, err := help.BlockWith(hc)
+		return template.HTML(s), err
+	}
+
+	if c.extends != "" {
+		c.blocks[name] = append(c.blocks[name], func(super func() (template.HTML, error)) (template.HTML, error) {
+			return render(help, super)
+		})
+		return "", nil
+	}
+
+	blocks := c.blocks
+	if blocks == nil {
+		blocks, _ = help.Value(templateBlocksKey).(templateBlocks)
+	}
+	overrides := blocks[name]
+
+	var renderAt func(i int) (template.HTML, error)
+	renderAt = func(i int) (template.HTML, error) {
+		super := func() (template.HTML, error) {
+			return renderAt(i + 1)
+		}
+		if i < len(overrides) {
+			return overrides[i](super)
+		}
+		return render(help, func() (template.HTML, error) {
+			return "", fmt.Errorf("block %q does not override any block", name)
+		})
+	}
+	return renderAt(0)
+}
+
+// renderExtended renders the template extended by the compiled template,
+// handing it the blocks the compiled template overrides.
+func (c *compiler) renderExtended() (string, error) {
+	name := c.extends
+	c.extends = ""
+
+	s, err := PartialHelper(name, map[string]interface{}{templateBlocksKey: c.blocks}, HelperContext{
+		Context:  c.ctx,
+		compiler: c,
+	})
+	return string(s), err
+}
@@ -0,0 +1,73 @@
+package plush_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/plush/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_StringInterpolation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		name     string
+	}{
+		{`<%= "btn btn-#{kind} #{size}" %>`, "btn btn-primary lg", "identifiers"},
+		{`<%= "#{count + 1} items" %>`, "4 items", "expression"},
+		{`<%= "#{upcase(kind)}!" %>`, "PRIMARY!", "helper_call"},
+		{`<%= "#{kind}#{size}" %>`, "primarylg", "adjacent"},
+		{`<%= "a #{ {"b": kind}["b"] } c" %>`, "a primary c", "hash_literal"},
+		{`<%= "#{"[#{size}]"}" %>`, "[lg]", "nested"},
+		{`<%= "#{kind == "primary" ? "yes" : "no"}" %>`, "yes", "ternary"},
+		{`<%= "#{missing?.name}-" %>`, "-", "nil"},
+		{`<%= "<#{kind}>" %>`, "&lt;primary&gt;", "escaped"},
+		{`<%= "#{date}" %>`, "January 02, 2006 15:04:05 +0000", "time"},
+		{`<%= "[#{noDate}]" %>`, "[]", "nil_time_pointer"},
+		{`<%= "\#{kind}" %>`, "#{kind}", "escaped_interpolation"},
+		{"<%= `#{kind}` %>", "#{kind}", "backtick_literal"},
+		{`<%= "#{ kind
+		}" %>`, "primary", "multiline"},
+		{`<% let greet = fn(name) { return "hi #{name}" } %><%= greet("mark") %>`, "hi mark", "function"},
+		{`<%= if ("#{kind}-#{size}" == "primary-lg") { %>match<% } %>`, "match", "condition"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			ctx := plush.NewContext()
+			ctx.Set("kind", "primary")
+			ctx.Set("size", "lg")
+			ctx.Set("count", 3)
+			ctx.Set("date", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC))
+			ctx.Set("noDate", (*time.Time)(nil))
+			s, err := plush.Render(tc.input, ctx)
+			r.NoError(err)
+			r.Equal(tc.expected, s)
+		})
+	}
+}
+
+func Test_StringInterpolation_Errors(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+		name  string
+	}{
+		{`<%= "a #{} b" %>`, "line 1: empty interpolation in string", "empty"},
+		{`<%= "a #{ kind b" %>`, "line 1: expected } to close the interpolation in string", "unclosed"},
+		{"<%= \"a\n#{unknown}\" %>", "line 1: \"unknown\": unknown identifier", "unknown_identifier"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			ctx := plush.NewContext()
+			ctx.Set("kind", "primary")
+			_, err := plush.Render(tc.input, ctx)
+			r.Error(err)
+			r.Contains(err.Error(), tc.err)
+		})
+	}
+}
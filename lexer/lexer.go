@@ -1,11 +1,47 @@
 package lexer
 
 import (
+	"strconv"
 	"strings"
 
 	"github.com/gobuffalo/plush/v5/token"
 )
 
+// Delims are the strings opening and closing a tag. Expression, comment
+// and hole tags are opened by Left followed by =, # and H respectively.
+type Delims struct {
+	Left  string
+	Right string
+}
+
+// DefaultDelims are the <% and %> delimiters.
+var DefaultDelims = Delims{Left: "<%", Right: "%>"}
+
+// RawStart opens a verbatim block, its content is emitted as HTML without
+// being lexed, so it may contain the delimiters itself.
+func (d Delims) RawStart() string {
+	return d.Left + "raw" + d.Right
+}
+
+// RawEnd closes a verbatim block.
+func (d Delims) RawEnd() string {
+	return d.Left + "endraw" + d.Right
+}
+
+// Options change how the lexer tokenizes its input.
+type Options struct {
+	// TrimBlocks removes the newline following a tag that does not print
+	// anything, such as <% let x = 1 %>, a comment, or a tag opening or
+	// closing a block like <%= for (x) in xs { %> and <% } %>. The
+	// indentation in front of such a tag is removed as well when nothing
+	// else precedes it on its line.
+	TrimBlocks bool
+
+	// Delims are the tag delimiters, DefaultDelims are used for the
+	// ones left empty.
+	Delims Delims
+}
+
 // Lexer moves through the source input and tokenizes its content
 type Lexer struct {
 	input        string
@@ -14,19 +50,54 @@ type Lexer struct {
 	ch           byte // current char under examination
 	inside       bool
 	curLine      int
+	opts         Options
+	delims       Delims
+
+	// interps holds the depth of the braces opened in each interpolation
+	// of a string being lexed, "#{ {"a": 1}["a"] }"
+	interps []int
+
+	// state of the tag being lexed, used to trim the surrounding HTML
+	tagType     token.Type
+	tagFirst    token.Type
+	prevType    token.Type
+	blockTag    bool
+	trimSpace   bool // set by -%>, trims all leading whitespace of the next HTML
+	trimNewline bool // set by TrimBlocks, trims one leading newline of the next HTML
 }
 
 // New Lexer from the input string
 func New(input string) *Lexer {
-	l := &Lexer{input: input, curLine: 1}
+	return NewWithOptions(input, Options{})
+}
+
+// NewWithOptions returns a Lexer for the input string using the given
+// options.
+func NewWithOptions(input string, opts Options) *Lexer {
+	l := &Lexer{input: input, curLine: 1, opts: opts, delims: opts.Delims}
+	if l.delims.Left == "" {
+		l.delims.Left = DefaultDelims.Left
+	}
+	if l.delims.Right == "" {
+		l.delims.Right = DefaultDelims.Right
+	}
 	l.readChar()
 	return l
 }
 
+// Delims returns the tag delimiters used by the lexer.
+func (l *Lexer) Delims() Delims {
+	return l.delims
+}
+
 // NextToken from the source input
 func (l *Lexer) NextToken() token.Token {
+	if l.at(l.delims.RawStart()) {
+		return l.readRaw()
+	}
+
 	if l.inside {
-		return l.nextInsideToken()
+		return l.nextTagToken()
 	}
 
 	var tok token.Token
@@ -39,23 +110,170 @@ func (l *Lexer) NextToken() token.Token {
 		return tok
 	}
 
-	if l.ch == '<' && l.peekChar() == '%' {
+	if l.at(l.delims.Left) {
 		l.inside = true
-		return l.nextInsideToken()
+		return l.nextTagToken()
 	}
 
 	tok.Type = token.HTML
-	tok.Literal = l.readHTML()
+	tok.Literal = l.trimHTML(l.readHTML())
 	tok.LineNumber = l.curLine
 
 	return tok
 }
 
+// readRaw reads a verbatim block and returns its content, untouched, as a
+// single HTML token. An unterminated block is returned as an ILLEGAL token.
+func (l *Lexer) readRaw() token.Token {
+	l.inside = false
+	l.trimSpace = false
+	l.trimNewline = false
+
+	start := l.position + len(l.delims.RawStart())
+	end := strings.Index(l.input[start:], l.delims.RawEnd())
+	if end < 0 {
+		tok := token.Token{Type: token.ILLEGAL, Literal: l.delims.RawStart(), LineNumber: l.curLine}
+		for l.ch != 0 {
+			l.readChar()
+		}
+		return tok
+	}
+
+	l.skip(start + end + len(l.delims.RawEnd()) - l.position)
+	return token.Token{Type: token.HTML, Literal: l.input[start : start+end], LineNumber: l.curLine}
+}
+
+// nextTagToken returns the next token inside of a tag and keeps track of
+// the shape of the tag for whitespace control.
+func (l *Lexer) nextTagToken() token.Token {
+	tok := l.nextInsideToken()
+
+	switch tok.Type {
+	case token.S_START, token.E_START, token.C_START:
+		l.tagType = tok.Type
+		l.tagFirst = ""
+		l.trimSpace = false
+		l.trimNewline = false
+	case token.E_END:
+		l.blockTag = l.tagType == token.S_START || l.tagType == token.C_START ||
+			l.tagFirst == token.RBRACE || l.prevType == token.LBRACE
+		l.trimNewline = l.opts.TrimBlocks && l.blockTag
+		l.trimSpace = tok.Literal == "-"+l.delims.Right
+	default:
+		if l.tagFirst == "" {
+			l.tagFirst = tok.Type
+		}
+	}
+	l.prevType = tok.Type
+
+	return tok
+}
+
+// trimHTML applies whitespace control to an HTML token, based on the tag
+// before it and the tag following it.
+func (l *Lexer) trimHTML(s string) string {
+	switch {
+	case l.trimSpace:
+		s = strings.TrimLeft(s, " \t\r\n")
+	case l.trimNewline:
+		if strings.HasPrefix(s, "\r\n") {
+			s = s[2:]
+		} else if strings.HasPrefix(s, "\n") {
+			s = s[1:]
+		}
+	}
+	l.trimSpace = false
+	l.trimNewline = false
+
+	if !l.inside {
+		return s
+	}
+
+	if l.hasTrimMarker() {
+		return strings.TrimRight(s, " \t\r\n")
+	}
+
+	if l.opts.TrimBlocks {
+		indent := strings.TrimRight(s, " \t")
+		if (indent == "" || strings.HasSuffix(indent, "\n")) && l.isBlockTag() {
+			return indent
+		}
+	}
+
+	return s
+}
+
+// hasTrimMarker reports whether the tag starting at the current position
+// opens with a whitespace trimming marker: <%-, <%=- or <%#- followed by
+// whitespace, so that <%-1%> or <%=-x %> are not mistaken for it.
+func (l *Lexer) hasTrimMarker() bool {
+	rest := l.input[l.position:]
+	for _, start := range []string{l.delims.Left + "-", l.delims.Left + "=-", l.delims.Left + "#-"} {
+		if strings.HasPrefix(rest, start) && len(rest) > len(start) && isWhitespace(rest[len(start)]) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBlockTag lexes the tag starting at the current position ahead of time
+// and reports whether it is a tag that prints nothing.
+func (l *Lexer) isBlockTag() bool {
+	ll := NewWithOptions(l.input[l.position:], Options{Delims: l.delims})
+	for {
+		tok := ll.NextToken()
+		switch tok.Type {
+		case token.E_END:
+			return ll.blockTag
+		case token.EOF, token.H_START, token.HTML:
+			return false
+		}
+	}
+}
+
 func (l *Lexer) nextInsideToken() token.Token {
 	var tok token.Token
 
 	l.skipWhitespace()
 
+	if l.at(l.delims.Left) {
+		l.inside = true
+		l.interps = nil
+		l.skip(len(l.delims.Left) - 1)
+		switch l.peekChar() {
+		case 'H':
+			l.readChar()
+			tok = token.Token{Type: token.H_START, Literal: l.readHString(), LineNumber: l.curLine}
+			l.inside = false
+		case '#':
+			l.readChar()
+			tok = token.Token{Type: token.C_START, Literal: l.delims.Left + "#", LineNumber: l.curLine}
+			l.skipTrimMarker()
+		case '=':
+			l.readChar()
+			tok = token.Token{Type: token.E_START, Literal: l.delims.Left + "=", LineNumber: l.curLine}
+			l.skipTrimMarker()
+		default:
+			tok = token.Token{Type: token.S_START, Literal: l.delims.Left, LineNumber: l.curLine}
+			l.skipTrimMarker()
+		}
+		l.readChar()
+		tok.LineNumber = l.curLine
+		return tok
+	}
+
+	if l.at(l.delims.Right) || l.isTrimEnd() {
+		tok = token.Token{Type: token.E_END, Literal: l.delims.Right, LineNumber: l.curLine}
+		if l.ch == '-' {
+			tok.Literal = "-" + l.delims.Right
+		}
+		l.inside = false
+		l.interps = nil
+		l.skip(len(tok.Literal))
+		tok.LineNumber = l.curLine
+		return tok
+	}
+
 	switch l.ch {
 	case '=':
 		if l.peekChar() == '=' {
@@ -66,18 +284,16 @@ func (l *Lexer) nextInsideToken() token.Token {
 			tok = l.newToken(token.ASSIGN)
 		}
 	case '.':
+		if l.peekChar() == '.' && l.readPosition+1 < len(l.input) && l.input[l.readPosition+1] == '.' {
+			l.skip(2)
+			tok = token.Token{Type: token.ELLIPSIS, Literal: "...", LineNumber: l.curLine}
+			break
+		}
 		if isDigit(l.peekChar()) {
-			tok.Literal = l.readNumber()
-			tokSplit := strings.Split(tok.Literal, ".")
-			switch {
-			case len(tokSplit) > 2:
-				return l.newIllegalTokenLiteral(token.ILLEGAL, tok.Literal)
-			case len(tokSplit) == 2:
-				tok.Type = "FLOAT"
-			default:
-				tok.Type = "INT"
+			tok = l.readNumberToken()
+			if tok.Type == token.ILLEGAL {
+				return tok
 			}
-
 			break
 		}
 		tok = l.newToken(token.DOT)
@@ -96,7 +312,7 @@ func (l *Lexer) nextInsideToken() token.Token {
 			tok = token.Token{Type: token.OR, Literal: "||", LineNumber: l.curLine}
 			break
 		}
-		tok = l.newToken(token.ILLEGAL)
+		tok = l.newToken(token.PIPE)
 	case '-':
 		tok = l.newToken(token.MINUS)
 	case '!':
@@ -110,35 +326,15 @@ func (l *Lexer) nextInsideToken() token.Token {
 	case '/':
 		tok = l.newToken(token.SLASH)
 	case '*':
-		tok = l.newToken(token.ASTERISK)
-	case '%':
-		if l.peekChar() == '>' {
-			l.inside = false
+		if l.peekChar() == '*' {
 			l.readChar()
-			tok = token.Token{Type: token.E_END, Literal: "%>", LineNumber: l.curLine}
+			tok = token.Token{Type: token.POWER, Literal: "**", LineNumber: l.curLine}
 			break
 		}
-		tok = l.newToken(token.ILLEGAL)
+		tok = l.newToken(token.ASTERISK)
+	case '%':
+		tok = l.newToken(token.PERCENT)
 	case '<':
-		if l.peekChar() == '%' {
-			l.inside = true
-			l.readChar()
-			switch l.peekChar() {
-			case 'H':
-				l.readChar()
-				tok = token.Token{Type: token.H_START, Literal: l.readHString(), LineNumber: l.curLine}
-				l.inside = false
-			case '#':
-				l.readChar()
-				tok = token.Token{Type: token.C_START, Literal: "<%#", LineNumber: l.curLine}
-			case '=':
-				l.readChar()
-				tok = token.Token{Type: token.E_START, Literal: "<%=", LineNumber: l.curLine}
-			default:
-				tok = token.Token{Type: token.S_START, Literal: "<%", LineNumber: l.curLine}
-			}
-			break
-		}
 		if l.peekChar() == '=' {
 			l.readChar()
 			tok = token.Token{Type: token.LTEQ, Literal: "<=", LineNumber: l.curLine}
@@ -163,19 +359,38 @@ func (l *Lexer) nextInsideToken() token.Token {
 		tok = l.newToken(token.SEMICOLON)
 	case ':':
 		tok = l.newToken(token.COLON)
+	case '?':
+		if l.peekChar() == '?' {
+			l.readChar()
+			tok = token.Token{Type: token.NULLISH, Literal: "??", LineNumber: l.curLine}
+			break
+		}
+		tok = l.newToken(token.QUESTION)
 	case ',':
 		tok = l.newToken(token.COMMA)
 	case '{':
+		if n := len(l.interps); n > 0 {
+			l.interps[n-1]++
+		}
 		tok = l.newToken(token.LBRACE)
 	case '}':
+		n := len(l.interps)
+		if n > 0 && l.interps[n-1] == 0 {
+			// the interpolation is closed, the string goes on
+			l.interps = l.interps[:n-1]
+			tok = l.stringToken(token.I_STRING_MID, token.I_STRING_END)
+			break
+		}
+		if n > 0 {
+			l.interps[n-1]--
+		}
 		tok = l.newToken(token.RBRACE)
 	case '(':
 		tok = l.newToken(token.LPAREN)
 	case ')':
 		tok = l.newToken(token.RPAREN)
 	case '"':
-		tok.Type = token.STRING
-		tok.Literal = l.readString()
+		tok = l.stringToken(token.I_STRING, token.STRING)
 	case '`':
 		tok.Type = token.B_STRING
 		tok.Literal = l.readBString()
@@ -201,18 +416,7 @@ func (l *Lexer) nextInsideToken() token.Token {
 			tok.LineNumber = l.curLine
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Literal = l.readNumber()
-			tokSplit := strings.Split(tok.Literal, ".")
-			switch {
-			case len(tokSplit) > 2:
-				return l.newIllegalTokenLiteral(token.ILLEGAL, tok.Literal)
-			case len(tokSplit) == 2:
-				tok.Type = "FLOAT"
-			default:
-				tok.Type = "INT"
-			}
-			tok.LineNumber = l.curLine
-			return tok
+			return l.readNumberToken()
 		} else {
 			tok = l.newToken(token.ILLEGAL)
 		}
@@ -229,7 +433,7 @@ func (l *Lexer) skipWhitespace() {
 		return
 	}
 
-	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
+	for isWhitespace(l.ch) {
 		l.readChar()
 	}
 }
@@ -265,35 +469,173 @@ func (l *Lexer) prevChar() byte {
 
 func (l *Lexer) readIdentifier() string {
 	position := l.position
-	for isLetter(l.ch) || isDigit(l.ch) {
+	for (isLetter(l.ch) || isDigit(l.ch) || l.isSafeNavigation()) && !l.isTrimEnd() {
 		l.readChar()
 	}
 	return l.input[position:l.position]
 }
 
+// skipTrimMarker moves past the - of a <%- tag start. The marker has to be
+// followed by whitespace, see hasTrimMarker.
+func (l *Lexer) skipTrimMarker() {
+	if l.peekChar() == '-' && l.readPosition+1 < len(l.input) && isWhitespace(l.input[l.readPosition+1]) {
+		l.readChar()
+	}
+}
+
+// isTrimEnd reports whether the lexer is on the - of a -%> tag end
+func (l *Lexer) isTrimEnd() bool {
+	return l.ch == '-' && l.readPosition < len(l.input) &&
+		strings.HasPrefix(l.input[l.readPosition:], l.delims.Right)
+}
+
+// at reports whether the input at the current position starts with s
+func (l *Lexer) at(s string) bool {
+	return l.position < len(l.input) && strings.HasPrefix(l.input[l.position:], s)
+}
+
+// peekAt reports whether the input after the current char starts with s
+func (l *Lexer) peekAt(s string) bool {
+	return l.readPosition < len(l.input) && strings.HasPrefix(l.input[l.readPosition:], s)
+}
+
+// skip moves the lexer n characters forward
+func (l *Lexer) skip(n int) {
+	for i := 0; i < n; i++ {
+		l.readChar()
+	}
+}
+
+// isSafeNavigation reports whether the lexer is on the ? of a?.b
+func (l *Lexer) isSafeNavigation() bool {
+	return l.ch == '?' && l.peekChar() == '.' &&
+		l.readPosition+1 < len(l.input) && isLetter(l.input[l.readPosition+1])
+}
+
+// readNumberToken reads a number as an INT or a FLOAT token. Integers may
+// be hexadecimal 0xFF, octal 0o17 or binary 0b1010, and decimal numbers may
+// have an exponent 1.5e3. Digits may be separated by underscores, 1_000.
+// The literal is checked by the parser.
+func (l *Lexer) readNumberToken() token.Token {
+	lit := l.readNumber()
+	if strings.Count(lit, ".") > 1 {
+		return l.newIllegalTokenLiteral(token.ILLEGAL, lit)
+	}
+
+	tok := token.Token{Type: token.INT, Literal: lit, LineNumber: l.curLine}
+	if !hasBasePrefix(lit) && strings.ContainsAny(lit, ".eE") {
+		tok.Type = token.FLOAT
+	}
+	return tok
+}
+
 func (l *Lexer) readNumber() string {
 	position := l.position
-	for isDigit(l.ch) || isDot(l.ch) {
+	decimal := !hasBasePrefix(l.input[position:])
+	for isDigit(l.ch) || isLetter(l.ch) && l.ch != '-' {
 		l.readChar()
+		// the sign of an exponent, 1e-3
+		if decimal && (l.ch == '-' || l.ch == '+') && (l.prevChar() == 'e' || l.prevChar() == 'E') && isDigit(l.peekChar()) {
+			l.readChar()
+		}
 	}
 	return l.input[position:l.position]
 }
 
-func (l *Lexer) readString() string {
-	position := l.position + 1
-	for l.ch != 0 {
+// hasBasePrefix reports whether the number s starts with a 0x, 0o or 0b
+// base prefix.
+func hasBasePrefix(s string) bool {
+	return len(s) > 1 && s[0] == '0' && strings.ContainsRune("xXoObB", rune(s[1]))
+}
+
+// stringToken reads the rest of a double quoted string as a token of type
+// end, or of type interp if an interpolation is opened first.
+// An invalid escape sequence is returned as an ILLEGAL token.
+func (l *Lexer) stringToken(interp, end token.Type) token.Token {
+	tok := token.Token{Type: end, LineNumber: l.curLine}
+	var opened bool
+	var err error
+	tok.Literal, opened, err = l.readString()
+	if err != nil {
+		return l.newIllegalTokenLiteral(token.ILLEGAL, tok.Literal)
+	}
+	if opened {
+		tok.Type = interp
+		l.interps = append(l.interps, 0)
+	}
+	return tok
+}
+
+// readString reads a double quoted string up to its closing quote, or up to
+// the #{ opening an interpolation in which case it reports true. Escape
+// sequences are the ones of Go strings, \# is a literal #. On an invalid
+// escape sequence the rest of the string is skipped and the sequence is
+// returned with an error.
+func (l *Lexer) readString() (string, bool, error) {
+	var out strings.Builder
+	for {
 		l.readChar()
-		// check for quote escapes
-		if l.ch == '\\' && l.peekChar() == '"' {
+		switch {
+		case l.ch == 0 || l.ch == '"':
+			return out.String(), false, nil
+		case l.ch == '\\' && l.peekChar() == '#':
 			l.readChar()
+		case l.ch == '\\':
+			rest := l.input[l.position:]
+			v, multibyte, tail, err := strconv.UnquoteChar(rest, '"')
+			if err != nil {
+				seq := invalidEscape(rest)
+				l.skipString()
+				return seq, false, err
+			}
+			if multibyte {
+				out.WriteRune(v)
+			} else {
+				// \xNN and octal escapes are bytes
+				out.WriteByte(byte(v))
+			}
+			l.skip(len(rest) - len(tail) - 1)
+			continue
+		case l.ch == '#' && l.peekChar() == '{':
 			l.readChar()
+			return out.String(), true, nil
 		}
-		if l.ch == '"' {
-			break
+		out.WriteByte(l.ch)
+	}
+}
+
+// skipString moves to the closing quote of the string being read.
+func (l *Lexer) skipString() {
+	for l.ch != 0 && l.ch != '"' {
+		if l.ch == '\\' && l.peekChar() == '"' {
+			l.readChar()
 		}
+		l.readChar()
 	}
-	s := l.input[position:l.position]
-	return strings.Replace(s, "\\\"", "\"", -1)
+}
+
+// invalidEscape returns the invalid escape sequence s starts with.
+func invalidEscape(s string) string {
+	n := 2
+	if len(s) > 1 {
+		switch s[1] {
+		case 'x':
+			n = 4
+		case 'u':
+			n = 6
+		case 'U':
+			n = 10
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			n = 4
+		}
+	}
+	if i := strings.IndexAny(s[1:], "\"\n"); i >= 0 && i+1 < n {
+		n = i + 1
+	}
+	if n > len(s) {
+		n = len(s)
+	}
+	return s[:n]
 }
 
 func (l *Lexer) readBString() string {
@@ -316,35 +658,36 @@ func (l *Lexer) readHString() string {
 	for l.ch != 0 {
 		l.readChar()
 
+		// delimiters are skipped as a whole, they may contain braces
+		if l.at(l.delims.Right) {
+			if !foundOpenBrace || braceDepth == 0 {
+				end := l.position
+				l.skip(len(l.delims.Right) - 1)
+				return l.input[position:end]
+			}
+			l.skip(len(l.delims.Right) - 1)
+			continue
+		}
+		if l.at(l.delims.Left) {
+			l.skip(len(l.delims.Left) - 1)
+			continue
+		}
+
 		if l.ch == '{' {
 			braceDepth += 1
 			foundOpenBrace = true
 		} else if l.ch == '}' {
 			braceDepth -= 1
 		}
-
-		if l.ch == '%' && l.peekChar() == '>' {
-			if foundOpenBrace {
-				if braceDepth == 0 {
-					l.readChar()
-
-					break
-				}
-			} else {
-
-				l.readChar()
-
-				break
-			}
-		}
 	}
 	return l.input[position : l.position-1]
 }
 func (l *Lexer) readHTML() string {
 	position := l.position
+	left := l.delims.Left
 
 	for l.ch != 0 {
-		if l.ch == '\\' && l.prevChar() == '\\' && l.peekChar() == '<' {
+		if l.ch == '\\' && l.prevChar() == '\\' && l.peekAt(left) {
 			// escape escaping
 			l.readChar()
 			x := l.input[position : l.position-1]
@@ -352,19 +695,18 @@ func (l *Lexer) readHTML() string {
 		}
 
 		// allow for expression escaping using \<% foo %>
-		if l.ch == '\\' && l.peekChar() == '<' {
-			l.readChar()
-			l.readChar()
+		if l.ch == '\\' && l.peekAt(left) {
+			l.skip(len(left))
 		}
 
-		if l.ch == '<' && l.peekChar() == '%' {
+		if l.at(left) {
 			l.inside = true
 			break
 		}
 
 		l.readChar()
 	}
-	return strings.Replace(l.input[position:l.position], "\\<%", "<%", -1)
+	return strings.Replace(l.input[position:l.position], "\\"+left, left, -1)
 }
 
 func isLetter(ch byte) bool {
@@ -375,8 +717,8 @@ func isDigit(ch byte) bool {
 	return '0' <= ch && ch <= '9' || ch == '.'
 }
 
-func isDot(ch byte) bool {
-	return ch == '.'
+func isWhitespace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
 }
 
 func (l *Lexer) newToken(tokenType token.Type) token.Token {
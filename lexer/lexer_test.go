@@ -421,3 +421,248 @@ my-helper()
 		r.Equal(tt.expectedType, tok.Type)
 	}
 }
+
+func Test_NextToken_Ternary(t *testing.T) {
+	r := require.New(t)
+	input := `<%= x ? "a" : "b" %>`
+	tests := []struct {
+		tokenType    token.Type
+		tokenLiteral string
+	}{
+		{token.E_START, "<%="},
+		{token.IDENT, "x"},
+		{token.QUESTION, "?"},
+		{token.STRING, "a"},
+		{token.COLON, ":"},
+		{token.STRING, "b"},
+		{token.E_END, "%>"},
+	}
+
+	l := lexer.New(input)
+	for _, tt := range tests {
+		tok := l.NextToken()
+		r.Equal(tt.tokenType, tok.Type)
+		r.Equal(tt.tokenLiteral, tok.Literal)
+	}
+}
+
+func Test_NextToken_SafeNavigation(t *testing.T) {
+	r := require.New(t)
+	input := `<%= user?.Profile?.Avatar ?? "none" %>`
+	tests := []struct {
+		tokenType    token.Type
+		tokenLiteral string
+	}{
+		{token.E_START, "<%="},
+		{token.IDENT, "user?.Profile?.Avatar"},
+		{token.NULLISH, "??"},
+		{token.STRING, "none"},
+		{token.E_END, "%>"},
+	}
+
+	l := lexer.New(input)
+	for _, tt := range tests {
+		tok := l.NextToken()
+		r.Equal(tt.tokenType, tok.Type)
+		r.Equal(tt.tokenLiteral, tok.Literal)
+	}
+}
+
+func Test_NextToken_TrimMarkers(t *testing.T) {
+	r := require.New(t)
+	input := "a  \n<%=- x -%>  \nb<%-1%>"
+	tests := []struct {
+		tokenType    token.Type
+		tokenLiteral string
+	}{
+		{token.HTML, "a"},
+		{token.E_START, "<%="},
+		{token.IDENT, "x"},
+		{token.E_END, "-%>"},
+		{token.HTML, "b"},
+		{token.S_START, "<%"},
+		{token.MINUS, "-"},
+		{token.INT, "1"},
+		{token.E_END, "%>"},
+	}
+
+	l := lexer.New(input)
+	for _, tt := range tests {
+		tok := l.NextToken()
+		r.Equal(tt.tokenType, tok.Type)
+		r.Equal(tt.tokenLiteral, tok.Literal)
+	}
+}
+
+func Test_NextToken_Raw(t *testing.T) {
+	r := require.New(t)
+	input := "a<%raw%><%= x %>\n%><%endraw%><%= y %>"
+	tests := []struct {
+		tokenType    token.Type
+		tokenLiteral string
+	}{
+		{token.HTML, "a"},
+		{token.HTML, "<%= x %>\n%>"},
+		{token.E_START, "<%="},
+		{token.IDENT, "y"},
+		{token.E_END, "%>"},
+		{token.EOF, ""},
+	}
+
+	l := lexer.New(input)
+	for _, tt := range tests {
+		tok := l.NextToken()
+		r.Equal(tt.tokenType, tok.Type)
+		r.Equal(tt.tokenLiteral, tok.Literal)
+	}
+}
+
+func Test_NextToken_Delims(t *testing.T) {
+	r := require.New(t)
+	input := `<%= a %>{{= b -}}{{ if (c) { }}{{# d }}{{H e }}`
+	tests := []struct {
+		tokenType    token.Type
+		tokenLiteral string
+	}{
+		{token.HTML, "<%= a %>"},
+		{token.E_START, "{{="},
+		{token.IDENT, "b"},
+		{token.E_END, "-}}"},
+		{token.S_START, "{{"},
+		{token.IF, "if"},
+		{token.LPAREN, "("},
+		{token.IDENT, "c"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.E_END, "}}"},
+		{token.C_START, "{{#"},
+		{token.IDENT, "d"},
+		{token.E_END, "}}"},
+		{token.H_START, " e "},
+		{token.EOF, ""},
+	}
+
+	l := lexer.NewWithOptions(input, lexer.Options{Delims: lexer.Delims{Left: "{{", Right: "}}"}})
+	for _, tt := range tests {
+		tok := l.NextToken()
+		r.Equal(tt.tokenType, tok.Type)
+		r.Equal(tt.tokenLiteral, tok.Literal)
+	}
+}
+
+func Test_NextToken_InterpolatedString(t *testing.T) {
+	r := require.New(t)
+	input := `<%= "btn-#{ {"a": kind}["a"] } #{"x#{y}"} \#{z}" + ` + "`#{w}`" + ` %>`
+	tests := []struct {
+		tokenType    token.Type
+		tokenLiteral string
+	}{
+		{token.E_START, "<%="},
+		{token.I_STRING, "btn-"},
+		{token.LBRACE, "{"},
+		{token.STRING, "a"},
+		{token.COLON, ":"},
+		{token.IDENT, "kind"},
+		{token.RBRACE, "}"},
+		{token.LBRACKET, "["},
+		{token.STRING, "a"},
+		{token.RBRACKET, "]"},
+		{token.I_STRING_MID, " "},
+		{token.I_STRING, "x"},
+		{token.IDENT, "y"},
+		{token.I_STRING_END, ""},
+		{token.I_STRING_END, " #{z}"},
+		{token.PLUS, "+"},
+		{token.B_STRING, "#{w}"},
+		{token.E_END, "%>"},
+		{token.EOF, ""},
+	}
+
+	l := lexer.New(input)
+	for _, tt := range tests {
+		tok := l.NextToken()
+		r.Equal(tt.tokenType, tok.Type)
+		r.Equal(tt.tokenLiteral, tok.Literal)
+	}
+}
+
+func Test_NextToken_StringEscapes(t *testing.T) {
+	r := require.New(t)
+	input := `<%= "a\n\t\\\"\u00e9\x41" "\q" "b" %>`
+	tests := []struct {
+		tokenType    token.Type
+		tokenLiteral string
+	}{
+		{token.E_START, "<%="},
+		{token.STRING, "a\n\t\\\"éA"},
+		{token.ILLEGAL, `\q`},
+		{token.STRING, "b"},
+		{token.E_END, "%>"},
+		{token.EOF, ""},
+	}
+
+	l := lexer.New(input)
+	for _, tt := range tests {
+		tok := l.NextToken()
+		r.Equal(tt.tokenType, tok.Type)
+		r.Equal(tt.tokenLiteral, tok.Literal)
+	}
+}
+
+func Test_NextToken_Numbers(t *testing.T) {
+	r := require.New(t)
+	input := `<%= 0xFF 0b1010 1_000 1.5e3 2E-2 1e3-1 .5 3 %>`
+	tests := []struct {
+		tokenType    token.Type
+		tokenLiteral string
+	}{
+		{token.E_START, "<%="},
+		{token.INT, "0xFF"},
+		{token.INT, "0b1010"},
+		{token.INT, "1_000"},
+		{token.FLOAT, "1.5e3"},
+		{token.FLOAT, "2E-2"},
+		{token.FLOAT, "1e3"},
+		{token.MINUS, "-"},
+		{token.INT, "1"},
+		{token.FLOAT, ".5"},
+		{token.INT, "3"},
+		{token.E_END, "%>"},
+		{token.EOF, ""},
+	}
+
+	l := lexer.New(input)
+	for _, tt := range tests {
+		tok := l.NextToken()
+		r.Equal(tt.tokenType, tok.Type)
+		r.Equal(tt.tokenLiteral, tok.Literal)
+	}
+}
+
+func Test_NextToken_ArithmeticOperators(t *testing.T) {
+	r := require.New(t)
+	input := `<%= a % b ** +c * d %>`
+	tests := []struct {
+		tokenType    token.Type
+		tokenLiteral string
+	}{
+		{token.E_START, "<%="},
+		{token.IDENT, "a"},
+		{token.PERCENT, "%"},
+		{token.IDENT, "b"},
+		{token.POWER, "**"},
+		{token.PLUS, "+"},
+		{token.IDENT, "c"},
+		{token.ASTERISK, "*"},
+		{token.IDENT, "d"},
+		{token.E_END, "%>"},
+		{token.EOF, ""},
+	}
+
+	l := lexer.New(input)
+	for _, tt := range tests {
+		tok := l.NextToken()
+		r.Equal(tt.tokenType, tok.Type)
+		r.Equal(tt.tokenLiteral, tok.Literal)
+	}
+}
@@ -0,0 +1,43 @@
+package plush_test
+
+import (
+	"testing"
+
+	"github.com/gobuffalo/plush/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Render_For_Loop(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		name     string
+	}{
+		{`<%= for (v) in items { %><%= loop.index %><%= loop.index1 %> <% } %>`, "01 12 23 ", "index"},
+		{`<%= for (v) in items { %><%= loop.first %>/<%= loop.last %> <% } %>`, "true/false false/false false/true ", "first_and_last"},
+		{`<%= for (v) in items { %><%= loop.length %><% } %>`, "333", "length"},
+		{`<%= for (v) in items { %><%= loop.even %>/<%= loop.odd %> <% } %>`, "true/false false/true true/false ", "even_and_odd"},
+		{`<%= for (v) in items { %><%= v %><%= if (!loop.last) { %>, <% } %><% } %>`, "a, b, c", "separator"},
+		{`<%= for (k, v) in {"x": 1} { %><%= loop.index %><%= loop.first %><%= loop.last %><%= loop.length %><% } %>`, "0truetrue1", "map"},
+		{`<%= for (v) in range(1, 3) { %><%= loop.index1 %><%= loop.last %><%= loop.length %> <% } %>`, "1 2 3 ", "iterator_has_no_length"},
+		{`<%= for (v) in items { %><% if (loop.index == 1) { continue } %><%= loop.index %><% } %>`, "02", "continue"},
+		{`<%= for (x) in [1, 2] { %><%= for (y) in items { %><%= loop.parent.index %><%= loop.index %> <% } %><% } %>`, "00 01 02 10 11 12 ", "parent"},
+		{`<%= for (v) in items { %><%= loop.parent %><% } %>`, "", "no_parent"},
+		{`<%= for (v) in items { %><% } %><%= loop ?? "none" %>`, "none", "scoped_to_the_block"},
+		{`<% let loop = 5 %><%= for (v) in items { %><%= loop %><% } %>`, "555", "let_loop_is_kept"},
+		{`<% let loop = 5 %><%= for (x) in [1] { %><%= for (v) in items { %><%= loop %><% } %><% } %>`, "555", "let_loop_is_kept_in_nested_loops"},
+		{`<%= for (loop) in items { %><%= loop %><% } %>`, "abc", "loop_as_value_name"},
+		{`<%= for (loop, v) in items { %><%= loop %><% } %>`, "012", "loop_as_key_name"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			ctx := plush.NewContext()
+			ctx.Set("items", []string{"a", "b", "c"})
+			s, err := plush.Render(tc.input, ctx)
+			r.NoError(err)
+			r.Equal(tc.expected, s)
+		})
+	}
+}
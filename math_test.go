@@ -2,6 +2,7 @@ package plush_test
 
 import (
 	"fmt"
+	"math"
 	"testing"
 
 	"github.com/gobuffalo/plush/v5"
@@ -26,6 +27,108 @@ func Test_Render_Int_Float_Division_By_Zero(t *testing.T) {
 	r.Contains(err.Error(), "division by zero 10.5 / 0")
 }
 
+func Test_Render_Int_Math_Modulo_By_Zero(t *testing.T) {
+	r := require.New(t)
+	input := `<%= 10 % 0 %>`
+	s, err := plush.Render(input, plush.NewContext())
+	r.Error(err)
+	r.Empty(s)
+	r.Contains(err.Error(), "division by zero 10 % 0")
+}
+
+func Test_Render_Float_Math_Modulo_By_Zero(t *testing.T) {
+	r := require.New(t)
+	input := `<%= 10.5 % 0.0 %>`
+	s, err := plush.Render(input, plush.NewContext())
+	r.Error(err)
+	r.Empty(s)
+	r.Contains(err.Error(), "division by zero 10.5 % 0")
+}
+
+func Test_Render_Arithmetic_Operators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		name     string
+	}{
+		{`<%= 7%3 %>`, "1", "modulo_without_spaces"},
+		{`<%= -7 % 3 %>`, "-1", "modulo_negative"},
+		{`<%= 10 % 4 * 3 %>`, "6", "modulo_product_precedence"},
+		{`<%= 2 ** 3 ** 2 %>`, "512", "power_right_associative"},
+		{`<%= 1 + 2 * 3 ** 2 %>`, "19", "power_precedence"},
+		{`<%= 2 ** 0 %>`, "1", "power_zero"},
+		{`<%= 2 ** -2 %>`, "0.25", "power_negative_exponent"},
+		{`<%= -2 ** 2 %>`, "-4", "power_unary_minus"},
+		{`<%= (-2) ** 2 %>`, "4", "power_grouped_unary_minus"},
+		{`<%= 2 ** 62 %>`, "4611686018427387904", "power_large"},
+		{`<%= (-2) ** 63 %>`, "-9223372036854775808", "power_min_int"},
+		{`<%= +5 %>`, "5", "unary_plus"},
+		{`<%= +1.5 %>`, "1.5", "unary_plus_float"},
+		{`<%= -(2 + 3) %>`, "-5", "unary_minus_group"},
+		{`<%= for (i, x) in [1, 2, 3] { %><%= i % 2 == 0 ? "even" : "odd" %> <% } %>`, "even odd even ", "zebra_striping"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			s, err := plush.Render(tc.input, plush.NewContext())
+			r.NoError(err)
+			r.Equal(tc.expected, s)
+		})
+	}
+}
+
+func Test_Render_Power_Overflow(t *testing.T) {
+	r := require.New(t)
+	for _, input := range []string{`<%= 2 ** 64 %>`, `<%= 2 ** 63 %>`, `<%= 10 ** 19 %>`} {
+		s, err := plush.Render(input, plush.NewContext())
+		r.Error(err, input)
+		r.Empty(s)
+		r.Contains(err.Error(), "integer overflow")
+	}
+}
+
+func Test_Render_Int_Overflow(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		name     string
+		success  bool
+	}{
+		{`<%= max + 0 %>`, "9223372036854775807", "max_plus_zero", true},
+		{`<%= min + max %>`, "-1", "min_plus_max", true},
+		{`<%= max - max %>`, "0", "max_minus_max", true},
+		{`<%= min * 1 %>`, "-9223372036854775808", "min_times_one", true},
+		{`<%= max * -1 %>`, "-9223372036854775807", "max_times_minus_one", true},
+		{`<%= min / 1 %>`, "-9223372036854775808", "min_divided_by_one", true},
+		{`<%= max + 1 %>`, "integer overflow 9223372036854775807 + 1", "max_plus_one", false},
+		{`<%= min + -1 %>`, "integer overflow -9223372036854775808 + -1", "min_plus_minus_one", false},
+		{`<%= min - 1 %>`, "integer overflow -9223372036854775808 - 1", "min_minus_one", false},
+		{`<%= max - -1 %>`, "integer overflow 9223372036854775807 - -1", "max_minus_minus_one", false},
+		{`<%= 0 - min %>`, "integer overflow 0 - -9223372036854775808", "zero_minus_min", false},
+		{`<%= max * 2 %>`, "integer overflow 9223372036854775807 * 2", "max_times_two", false},
+		{`<%= min * -1 %>`, "integer overflow -9223372036854775808 * -1", "min_times_minus_one", false},
+		{`<%= min / -1 %>`, "integer overflow -9223372036854775808 / -1", "min_divided_by_minus_one", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			ctx := plush.NewContext()
+			ctx.Set("max", math.MaxInt64)
+			ctx.Set("min", math.MinInt64)
+			s, err := plush.Render(tc.input, ctx)
+			if tc.success {
+				r.NoError(err)
+				r.Equal(tc.expected, s)
+			} else {
+				r.Error(err)
+				r.Contains(err.Error(), tc.expected)
+			}
+		})
+	}
+}
+
 func Test_Render_Int_Math(t *testing.T) {
 	r := require.New(t)
 
@@ -39,6 +142,8 @@ func Test_Render_Int_Math(t *testing.T) {
 		{3, 1, "-", "2"},
 		{10, 2, "/", "5"},
 		{10, 2, "*", "20"},
+		{10, 4, "%", "2"},
+		{2, 10, "**", "1024"},
 		{10, 2, ">", "true"},
 		{10, 2, ">=", "true"},
 		{10, 10, ">=", "true"},
@@ -69,6 +174,8 @@ func Test_Render_Float_Math(t *testing.T) {
 		{3, 1, "-", "2"},
 		{10, 2, "/", "5"},
 		{10, 2, "*", "20"},
+		{10.5, 4, "%", "2.5"},
+		{2, 0.5, "**", "1.4142135623730951"},
 		{10, 2, ">", "true"},
 		{10, 2, ">=", "true"},
 		{10, 10, ">=", "true"},
@@ -0,0 +1,119 @@
+package plush_test
+
+import (
+	"testing"
+
+	"github.com/gobuffalo/plush/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type navProfile struct {
+	Avatar string
+}
+
+func (p *navProfile) URL() string {
+	return "/" + p.Avatar
+}
+
+type navUser struct {
+	Name    string
+	Profile *navProfile
+	Roles   []string
+}
+
+func Test_Nullish_Coalescing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		name     string
+	}{
+		{`<%= nope ?? "default" %>`, "default", "unknown_identifier"},
+		{`<%= nope ?? other ?? "last" %>`, "last", "chained"},
+		{`<%= "set" ?? "default" %>`, "set", "set_value"},
+		{`<%= false ?? "default" %>`, "false", "false_is_not_nil"},
+		{`<%= "" ?? "default" %>`, "", "empty_string_is_not_nil"},
+		{`<%= m["missing"] ?? "default" %>`, "default", "missing_map_index"},
+		{`<%= nope ?? 1 + 2 %>`, "3", "binds_looser_than_sum"},
+		{`<%= nope ?? "a" == "a" %>`, "true", "binds_looser_than_equals"},
+		{`<% let x = nope ?? "default" %><%= x %>`, "default", "let"},
+		{`<%= u.Profile ?? "no profile" %>`, "no profile", "nil_pointer"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			ctx := plush.NewContext()
+			ctx.Set("m", map[string]interface{}{})
+			ctx.Set("u", navUser{})
+			s, err := plush.Render(tc.input, ctx)
+			r.NoError(err)
+			r.Equal(tc.expected, s)
+		})
+	}
+}
+
+func Test_Nullish_Coalescing_Error(t *testing.T) {
+	r := require.New(t)
+	_, err := plush.Render(`<%= (1 / 0) ?? "default" %>`, plush.NewContext())
+	r.Error(err)
+	r.Contains(err.Error(), "division by zero")
+}
+
+func Test_Safe_Navigation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		name     string
+	}{
+		{`<%= nope?.Profile?.Avatar %>`, "", "unknown_identifier"},
+		{`<%= nope?.Profile?.Avatar ?? "none" %>`, "none", "unknown_identifier_coalesced"},
+		{`<%= empty?.Profile?.Avatar ?? "none" %>`, "none", "nil_field"},
+		{`<%= full?.Profile?.Avatar %>`, "me.png", "set"},
+		{`<%= nilUser?.Name ?? "anonymous" %>`, "anonymous", "nil_pointer"},
+		{`<%= m?.missing ?? "none" %>`, "none", "missing_map_key"},
+		{`<%= m?.key %>`, "value", "map_key"},
+		{`<%= m.key %>`, "value", "map_key_without_safe_navigation"},
+		{`<%= empty.Profile?.URL() ?? "no url" %>`, "no url", "method_on_nil"},
+		{`<%= full.Profile?.URL() %>`, "/me.png", "method"},
+		{`<%= nope?.Roles[0] ?? "none" %>`, "none", "index"},
+		{`<%= full?.Roles[0] %>`, "admin", "index_set"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			ctx := plush.NewContext()
+			ctx.Set("m", map[string]interface{}{"key": "value"})
+			ctx.Set("empty", navUser{})
+			ctx.Set("full", navUser{Profile: &navProfile{Avatar: "me.png"}, Roles: []string{"admin"}})
+			ctx.Set("nilUser", (*navUser)(nil))
+			s, err := plush.Render(tc.input, ctx)
+			r.NoError(err)
+			r.Equal(tc.expected, s)
+		})
+	}
+}
+
+func Test_Safe_Navigation_Errors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		name     string
+	}{
+		{`<%= nope.Profile %>`, `"nope": unknown identifier`, "unknown_identifier_without_safe_navigation"},
+		{`<%= m.missing %>`, "'m' does not have a key named 'missing' (m.missing)", "missing_map_key_without_safe_navigation"},
+		{`<%= full?.Missing %>`, "'full' does not have a field or method named 'Missing' (full?.Missing)", "missing_struct_field"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			ctx := plush.NewContext()
+			ctx.Set("m", map[string]interface{}{"key": "value"})
+			ctx.Set("full", navUser{})
+			_, err := plush.Render(tc.input, ctx)
+			r.Error(err)
+			r.Contains(err.Error(), tc.expected)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package plush_test
 
 import (
+	"math"
 	"testing"
 
 	"github.com/gobuffalo/plush/v5"
@@ -44,3 +45,108 @@ func Test_Render_AllowsManyNumericTypes(t *testing.T) {
 	r.NoError(err)
 	r.Equal("1 2 3", s)
 }
+
+func Test_Render_NumberLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		name     string
+	}{
+		{`<%= 0xFF %>`, "255", "hex"},
+		{`<%= 0b1010 %>`, "10", "binary"},
+		{`<%= 0o17 %>`, "15", "octal"},
+		{`<%= 1_000_000 %>`, "1000000", "underscores"},
+		{`<%= 00 %>`, "0", "zeros"},
+		{`<%= 010 %>`, "10", "leading_zero"},
+		{`<%= 1.5e3 %>`, "1500", "exponent"},
+		{`<%= 25e-2 %>`, "0.25", "negative_exponent"},
+		{`<%= 0x10 + 0b1 %>`, "17", "arithmetic"},
+		{`<%= if (0xFF == 255) { %>yes<% } %>`, "yes", "comparison"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			s, err := plush.Render(tc.input, plush.NewContext())
+			r.NoError(err)
+			r.Equal(tc.expected, s)
+		})
+	}
+}
+
+func Test_Render_NumberLiterals_Errors(t *testing.T) {
+	r := require.New(t)
+	_, err := plush.Render("<p>\n<%= 0xG1 %></p>", plush.NewContext())
+	r.Error(err)
+	r.Contains(err.Error(), `line 2: could not parse "0xG1" as integer`)
+}
+
+type numberID uint
+
+func Test_Render_NumericPromotion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		name     string
+		success  bool
+	}{
+		{`<%= i32 + 1 %>`, "4", "int32_int", true},
+		{`<%= i8 * i32 %>`, "-9", "int8_int32", true},
+		{`<%= i64 == 5 %>`, "true", "int64_int", true},
+		{`<%= u + 1 %>`, "8", "uint_int", true},
+		{`<%= u8 - 10 %>`, "-5", "uint8_below_zero", true},
+		{`<%= id * 2 %>`, "42", "named_uint", true},
+		{`<%= 1 + 0.5 %>`, "1.5", "int_float", true},
+		{`<%= 10 / 4.0 %>`, "2.5", "int_float_division", true},
+		{`<%= f32 * 2 %>`, "3", "float32_int", true},
+		{`<%= i32 == 3.0 %>`, "true", "int32_float_equal", true},
+		{`<%= i8 < u %>`, "true", "signed_unsigned", true},
+		{`<%= -u %>`, "-7", "negated_uint", true},
+		{`<%= -i8 %>`, "3", "negated_int8", true},
+		{`<%= big > -1 %>`, "true", "max_uint64_greater_than_negative", true},
+		{`<%= -1 < big %>`, "true", "negative_less_than_max_uint64", true},
+		{`<%= big == -1 %>`, "false", "max_uint64_not_equal_negative", true},
+		{`<%= big != -1 %>`, "true", "max_uint64_different_from_negative", true},
+		{`<%= big > u %>`, "true", "max_uint64_greater_than_uint", true},
+		{`<%= big - 1 %>`, "18446744073709551614", "max_uint64_arithmetic", true},
+		{`<%= big ** 1 %>`, "18446744073709551615", "max_uint64_power", true},
+		{`<%= huge + -1 %>`, "9223372036854775807", "negative_plus_large_uint", true},
+		{`<%= -1 + huge %>`, "9223372036854775807", "large_uint_plus_negative", true},
+		{`<%= huge * -1 %>`, "-9223372036854775808", "large_uint_times_negative", true},
+		{`<%= huge % -7 %>`, "1", "large_uint_modulo_negative", true},
+		{`<%= -7 / huge %>`, "0", "negative_divided_by_large_uint", true},
+		{`<%= big - -1 %>`, "integer overflow 18446744073709551615 - -1", "large_uint_minus_negative_overflow", false},
+		{`<%= -1 - huge %>`, "integer overflow -1 - 9223372036854775808", "negative_minus_large_uint_overflow", false},
+		{`<%= big ** 2 %>`, "integer overflow 18446744073709551615 ** 2", "uint64_power_overflow", false},
+		{`<%= if (u >= 7 && i32 < 3.5) { %>yes<% } %>`, "yes", "condition", true},
+		{`<%= big + 1 %>`, "integer overflow 18446744073709551615 + 1", "uint64_overflow", false},
+		{`<%= big * 2 %>`, "integer overflow 18446744073709551615 * 2", "uint64_multiplication_overflow", false},
+		{`<%= -big %>`, "integer overflow 0 - 18446744073709551615", "negated_max_uint64", false},
+		{`<%= u / 0 %>`, "division by zero 7 / 0", "uint_division_by_zero", false},
+		{`<%= i32 % 0 %>`, "division by zero 3 % 0", "int32_modulo_by_zero", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			ctx := plush.NewContext()
+			ctx.Set("i8", int8(-3))
+			ctx.Set("i32", int32(3))
+			ctx.Set("i64", int64(5))
+			ctx.Set("u", uint(7))
+			ctx.Set("u8", uint8(5))
+			ctx.Set("id", numberID(21))
+			ctx.Set("f32", float32(1.5))
+			ctx.Set("big", uint64(math.MaxUint64))
+			ctx.Set("huge", uint64(1<<63))
+			s, err := plush.Render(tc.input, ctx)
+			if tc.success {
+				r.NoError(err)
+				r.Equal(tc.expected, s)
+			} else {
+				r.Error(err)
+				r.Contains(err.Error(), tc.expected)
+			}
+		})
+	}
+}
@@ -17,7 +17,13 @@ type (
 
 // Parse the string and return an AST or an error
 func Parse(s string) (*ast.Program, error) {
-	p := newParser(lexer.New(s))
+	return ParseWithOptions(s, lexer.Options{})
+}
+
+// ParseWithOptions parses the string using the given lexer options and
+// returns an AST or an error
+func ParseWithOptions(s string, opts lexer.Options) (*ast.Program, error) {
+	p := newParser(lexer.NewWithOptions(s, opts))
 	prog := p.parseProgram()
 
 	if len(p.errors) > 0 {
@@ -41,13 +47,18 @@ func newParser(l *lexer.Lexer) *parser {
 	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
 	p.registerPrefix(token.B_STRING, p.parseStringLiteral)
+	p.registerPrefix(token.I_STRING, p.parseInterpolatedString)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
+	p.registerPrefix(token.PLUS, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.IF, p.parseIfExpression)
+	p.registerPrefix(token.UNLESS, p.parseIfExpression)
 	p.registerPrefix(token.FOR, p.parseForExpression)
+	p.registerPrefix(token.WHILE, p.parseWhileExpression)
+	p.registerPrefix(token.SWITCH, p.parseSwitchExpression)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
 	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
@@ -61,6 +72,8 @@ func newParser(l *lexer.Lexer) *parser {
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
 	p.registerInfix(token.SLASH, p.parseInfixExpression)
 	p.registerInfix(token.ASTERISK, p.parseInfixExpression)
+	p.registerInfix(token.PERCENT, p.parseInfixExpression)
+	p.registerInfix(token.POWER, p.parseInfixExpression)
 	p.registerInfix(token.EQ, p.parseInfixExpression)
 	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
 	p.registerInfix(token.MATCHES, p.parseInfixExpression)
@@ -70,6 +83,11 @@ func newParser(l *lexer.Lexer) *parser {
 	p.registerInfix(token.GTEQ, p.parseInfixExpression)
 	p.registerInfix(token.AND, p.parseInfixExpression)
 	p.registerInfix(token.OR, p.parseInfixExpression)
+	p.registerInfix(token.IN, p.parseInExpression)
+	p.registerInfix(token.NOT, p.parseInExpression)
+	p.registerInfix(token.QUESTION, p.parseTernaryExpression)
+	p.registerInfix(token.NULLISH, p.parseInfixExpression)
+	p.registerInfix(token.PIPE, p.parseFilterExpression)
 
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
@@ -87,6 +105,8 @@ type parser struct {
 
 	curToken  token.Token
 	peekToken token.Token
+	// ahead holds tokens read from the lexer past peekToken
+	ahead []token.Token
 
 	prefixParseFns map[token.Type]prefixParseFn
 	infixParseFns  map[token.Type]infixParseFn
@@ -120,9 +140,30 @@ func (p *parser) parseProgram() *ast.Program {
 
 func (p *parser) nextToken() {
 	p.curToken = p.peekToken
+	if len(p.ahead) > 0 {
+		p.peekToken = p.ahead[0]
+		p.ahead = p.ahead[1:]
+		return
+	}
 	p.peekToken = p.NextToken()
 }
 
+// lookahead returns the token after peekToken.
+func (p *parser) lookahead() token.Token {
+	if len(p.ahead) == 0 {
+		p.ahead = append(p.ahead, p.NextToken())
+	}
+	return p.ahead[0]
+}
+
+// peekNotIn turns the peeked identifier not into the not in operator when
+// it is followed by in, so not can still be used as a name.
+func (p *parser) peekNotIn() {
+	if p.peekTokenIs(token.IDENT) && p.peekToken.Literal == "not" && p.lookahead().Type == token.IN {
+		p.peekToken.Type = token.NOT
+	}
+}
+
 func (p *parser) curTokenIs(t token.Type) bool {
 	return p.curToken.Type == t
 }
@@ -152,6 +193,18 @@ func (p *parser) peekError(t token.Type) {
 }
 
 func (p *parser) noPrefixParseFnError(t token.Type) {
+	if d := p.Lexer.Delims(); t == token.ILLEGAL && p.curToken.Literal == d.RawStart() {
+		p.errors = append(p.errors, fmt.Sprintf("line %d: %s is missing its closing %s", p.curToken.LineNumber, d.RawStart(), d.RawEnd()))
+		return
+	}
+	if t == token.ILLEGAL && strings.HasPrefix(p.curToken.Literal, "\\") {
+		p.errors = append(p.errors, fmt.Sprintf("line %d: invalid escape sequence %s in string", p.curToken.LineNumber, p.curToken.Literal))
+		return
+	}
+	if t == token.ILLEGAL && p.curToken.Literal != "" && strings.ContainsRune("0123456789.", rune(p.curToken.Literal[0])) {
+		p.errors = append(p.errors, fmt.Sprintf("line %d: could not parse %q as number", p.curToken.LineNumber, p.curToken.Literal))
+		return
+	}
 	msg := fmt.Sprintf("line %d: no prefix parse function for %s found", p.curToken.LineNumber, t)
 	p.errors = append(p.errors, msg)
 }
@@ -179,10 +232,72 @@ func (p *parser) parseStatement() ast.Statement {
 	case token.EOF:
 		return nil
 	default:
-		return p.parseExpressionStatement()
+		stmt := p.parseExpressionStatement()
+		// as is not a keyword, so it can still be used as a name
+		if p.peekTokenIs(token.IDENT) && p.peekToken.Literal == "as" {
+			return p.parseImportAlias(stmt)
+		}
+		return stmt
 	}
 }
 
+// parseImportAlias turns `import("file") as name` into `let name = import("file")`
+func (p *parser) parseImportAlias(stmt *ast.ExpressionStatement) *ast.LetStatement {
+	let := &ast.LetStatement{TokenAble: stmt.TokenAble, Value: stmt.Expression}
+
+	call, ok := stmt.Expression.(*ast.CallExpression)
+	if !ok || call.Callee != nil || call.Function.String() != "import" {
+		msg := fmt.Sprintf("line %d: as can only follow import(...)", p.peekToken.LineNumber)
+		p.errors = append(p.errors, msg)
+	}
+
+	p.nextToken()
+	if !p.expectPeek(token.IDENT) {
+		return let
+	}
+	let.Name = &ast.Identifier{TokenAble: ast.TokenAble{Token: p.curToken}, Value: p.curToken.Literal}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return let
+}
+
+// parsePattern parses a destructuring pattern, [a, b] or {a, b}, starting
+// at its opening bracket or brace.
+func (p *parser) parsePattern() *ast.Pattern {
+	pattern := &ast.Pattern{TokenAble: ast.TokenAble{Token: p.curToken}}
+
+	closing := token.Type(token.RBRACE)
+	if pattern.IsArray() {
+		closing = token.RBRACKET
+	}
+
+	for {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		if strings.Contains(p.curToken.Literal, ".") {
+			msg := fmt.Sprintf("line %d: can not destructure into %s", p.curToken.LineNumber, p.curToken.Literal)
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+		pattern.Names = append(pattern.Names, &ast.Identifier{TokenAble: ast.TokenAble{Token: p.curToken}, Value: p.curToken.Literal})
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectPeek(closing) {
+		return nil
+	}
+
+	return pattern
+}
+
 func (p *parser) parseReturnStatement(t string) *ast.ReturnStatement {
 	stmt := &ast.ReturnStatement{Type: t, TokenAble: ast.TokenAble{Token: p.curToken}}
 
@@ -199,11 +314,18 @@ func (p *parser) parseReturnStatement(t string) *ast.ReturnStatement {
 func (p *parser) parseLetStatement() *ast.LetStatement {
 	stmt := &ast.LetStatement{TokenAble: ast.TokenAble{Token: p.curToken}}
 
-	if !p.expectPeek(token.IDENT) {
-		return stmt
-	}
+	if p.peekTokenIs(token.LBRACKET) || p.peekTokenIs(token.LBRACE) {
+		p.nextToken()
+		if stmt.Pattern = p.parsePattern(); stmt.Pattern == nil {
+			return stmt
+		}
+	} else {
+		if !p.expectPeek(token.IDENT) {
+			return stmt
+		}
 
-	stmt.Name = &ast.Identifier{TokenAble: ast.TokenAble{Token: p.curToken}, Value: p.curToken.Literal}
+		stmt.Name = &ast.Identifier{TokenAble: ast.TokenAble{Token: p.curToken}, Value: p.curToken.Literal}
+	}
 
 	if !p.expectPeek(token.ASSIGN) {
 		return stmt
@@ -244,6 +366,7 @@ func (p *parser) parseExpression(precedence int) ast.Expression {
 
 	leftExp := prefix()
 
+	p.peekNotIn()
 	for !p.peekTokenIs(token.SEMICOLON) && precedence < p.peekPrecedence() {
 		infix := p.infixParseFns[p.peekToken.Type]
 		if infix == nil {
@@ -252,6 +375,7 @@ func (p *parser) parseExpression(precedence int) ast.Expression {
 
 		p.nextToken()
 		leftExp = infix(leftExp)
+		p.peekNotIn()
 	}
 
 	return leftExp
@@ -277,11 +401,13 @@ func (p *parser) parseIdentifier() ast.Expression {
 	id := &ast.Identifier{TokenAble: ast.TokenAble{Token: p.curToken}}
 	orignalCalleAddress := id
 	ss := strings.Split(p.curToken.Literal, ".")
-	id.Value = ss[0]
+	id.Value = strings.TrimSuffix(ss[0], "?")
 
 	for i := 1; i < len(ss); i++ {
 		s := ss[i]
-		id = &ast.Identifier{TokenAble: ast.TokenAble{Token: p.curToken}, Value: s, Callee: id}
+		// a trailing ? on the previous segment marks safe navigation (a?.b)
+		safe := strings.HasSuffix(ss[i-1], "?")
+		id = &ast.Identifier{TokenAble: ast.TokenAble{Token: p.curToken}, Value: strings.TrimSuffix(s, "?"), Callee: id, Safe: safe}
 	}
 
 	//To avoid a recursive loop to reach the original calle address
@@ -351,14 +477,23 @@ func (p *parser) parseContinue() ast.Expression {
 func (p *parser) parseIntegerLiteral() ast.Expression {
 	lit := &ast.IntegerLiteral{TokenAble: ast.TokenAble{Token: p.curToken}}
 
-	value, err := strconv.Atoi(p.curToken.Literal)
+	// base 0 reads the 0x, 0o and 0b prefixes and underscores, leading zeros
+	// are trimmed so that 010 stays decimal, down to a single 0 for 00
+	s := p.curToken.Literal
+	if len(s) > 1 && s[0] == '0' && s[1] >= '0' && s[1] <= '9' {
+		s = strings.TrimLeft(s, "0")
+		if s == "" || s[0] == '_' {
+			s = "0" + s
+		}
+	}
+	value, err := strconv.ParseInt(s, 0, strconv.IntSize)
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
+		msg := fmt.Sprintf("line %d: could not parse %q as integer", p.curToken.LineNumber, p.curToken.Literal)
 		p.errors = append(p.errors, msg)
 		return nil
 	}
 
-	lit.Value = value
+	lit.Value = int(value)
 
 	return lit
 }
@@ -368,7 +503,7 @@ func (p *parser) parseFloatLiteral() ast.Expression {
 
 	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as float", p.curToken.Literal)
+		msg := fmt.Sprintf("line %d: could not parse %q as float", p.curToken.LineNumber, p.curToken.Literal)
 		p.errors = append(p.errors, msg)
 		return nil
 	}
@@ -382,6 +517,42 @@ func (p *parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{TokenAble: ast.TokenAble{Token: p.curToken}, Value: p.curToken.Literal}
 }
 
+func (p *parser) parseInterpolatedString() ast.Expression {
+	str := &ast.InterpolatedString{TokenAble: ast.TokenAble{Token: p.curToken}}
+
+	for {
+		str.Parts = append(str.Parts, &ast.StringLiteral{TokenAble: ast.TokenAble{Token: p.curToken}, Value: p.curToken.Literal})
+		if p.curTokenIs(token.I_STRING_END) {
+			return str
+		}
+
+		p.nextToken()
+		if p.curTokenIs(token.I_STRING_MID) || p.curTokenIs(token.I_STRING_END) {
+			msg := fmt.Sprintf("line %d: empty interpolation in string", p.curToken.LineNumber)
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+
+		exp := p.parseExpression(LOWEST)
+		if exp == nil {
+			return nil
+		}
+		str.Parts = append(str.Parts, exp)
+
+		if p.peekTokenIs(token.ILLEGAL) {
+			p.nextToken()
+			p.noPrefixParseFnError(p.curToken.Type)
+			return nil
+		}
+		if !p.peekTokenIs(token.I_STRING_MID) && !p.peekTokenIs(token.I_STRING_END) {
+			msg := fmt.Sprintf("line %d: expected } to close the interpolation in string, got %s", p.peekToken.LineNumber, p.peekToken.Literal)
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+		p.nextToken()
+	}
+}
+
 func (p *parser) parseCommentLiteral() ast.Expression {
 	for p.curToken.Type != token.E_END {
 		p.nextToken()
@@ -403,6 +574,12 @@ func (p *parser) parsePrefixExpression() ast.Expression {
 	p.nextToken()
 	expression.Right = p.parseExpression(PREFIX)
 
+	// the sign of a number binds less tightly than **, -2 ** 2 is -(2 ** 2)
+	if expression.Operator != "!" && p.peekTokenIs(token.POWER) {
+		p.nextToken()
+		expression.Right = p.parseInfixExpression(expression.Right)
+	}
+
 	return expression
 }
 
@@ -414,12 +591,98 @@ func (p *parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	}
 
 	precedence := p.curPrecedence()
+	if p.curTokenIs(token.POWER) {
+		// ** is right associative, 2 ** 3 ** 2 is 2 ** (3 ** 2)
+		precedence--
+	}
 	p.nextToken()
 	expression.Right = p.parseExpression(precedence)
 
 	return expression
 }
 
+func (p *parser) parseInExpression(left ast.Expression) ast.Expression {
+	expression := &ast.InfixExpression{
+		TokenAble: ast.TokenAble{Token: p.curToken},
+		Operator:  "in",
+		Left:      left,
+	}
+
+	if p.curTokenIs(token.NOT) {
+		expression.Operator = "not in"
+		if !p.expectPeek(token.IN) {
+			return nil
+		}
+	}
+
+	precedence := p.curPrecedence()
+	p.nextToken()
+	expression.Right = p.parseExpression(precedence)
+
+	// (x) in xs { ... } is a for loop missing its keyword, not a membership test
+	if p.peekTokenIs(token.LBRACE) {
+		msg := fmt.Sprintf("line %d: syntax error: unexpected { after %s, missing for keyword?", p.curToken.LineNumber, expression)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	return expression
+}
+
+func (p *parser) parseTernaryExpression(condition ast.Expression) ast.Expression {
+	expression := &ast.TernaryExpression{
+		TokenAble: ast.TokenAble{Token: p.curToken},
+		Condition: condition,
+	}
+
+	p.nextToken()
+	expression.Consequence = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.COLON) {
+		return nil
+	}
+
+	// parsing the alternative with LOWEST makes the operator right associative
+	// so that a ? b : c ? d : e reads as a ? b : (c ? d : e)
+	p.nextToken()
+	expression.Alternative = p.parseExpression(LOWEST)
+
+	return expression
+}
+
+func (p *parser) parseFilterExpression(left ast.Expression) ast.Expression {
+	expression := &ast.FilterExpression{
+		TokenAble: ast.TokenAble{Token: p.curToken},
+		Left:      left,
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	// the filtered value is passed as the first argument of the filter
+	switch f := p.parseExpression(FILTER).(type) {
+	case *ast.Identifier:
+		expression.Call = &ast.CallExpression{
+			TokenAble: f.TokenAble,
+			Function:  f,
+			Arguments: []ast.Expression{left},
+		}
+		if f.Callee != nil {
+			expression.Call.Callee = f.Callee
+		}
+	case *ast.CallExpression:
+		f.Arguments = append([]ast.Expression{left}, f.Arguments...)
+		expression.Call = f
+	default:
+		msg := fmt.Sprintf("line %d: syntax error: invalid filter %v", p.curToken.LineNumber, f)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	return expression
+}
+
 func (p *parser) parseBoolean() ast.Expression {
 	return &ast.Boolean{TokenAble: ast.TokenAble{Token: p.curToken}, Value: p.curTokenIs(token.TRUE)}
 }
@@ -455,6 +718,19 @@ func (p *parser) parseForExpression() ast.Expression {
 			s = append(s, p.curToken.Literal)
 		}
 
+		if expression.ValuePattern == nil && (p.curTokenIs(token.LPAREN) || p.curTokenIs(token.COMMA)) &&
+			(p.peekTokenIs(token.LBRACKET) || p.peekTokenIs(token.LBRACE)) {
+			p.nextToken()
+			if expression.ValuePattern = p.parsePattern(); expression.ValuePattern == nil {
+				return nil
+			}
+			s = append(s, expression.ValueName)
+			if !p.peekTokenIs(token.RPAREN) {
+				p.errors = append(p.errors, fmt.Sprintf("line %d: a destructuring pattern must be the value of the for loop", ln))
+				return nil
+			}
+		}
+
 		if p.peekTokenIs(token.LBRACE) || p.peekTokenIs(token.EOF) {
 			p.errors = append(p.errors, fmt.Sprintf("line %d: expected ) got %s", ln, p.peekToken.Literal))
 			return nil
@@ -503,6 +779,36 @@ func (p *parser) parseForExpression() ast.Expression {
 	return expression
 }
 
+func (p *parser) parseWhileExpression() ast.Expression {
+	expression := &ast.WhileExpression{TokenAble: ast.TokenAble{Token: p.curToken}}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	if p.curTokenIs(token.RPAREN) {
+		p.errors = append(p.errors, fmt.Sprintf("line %d: missing condition in while loop", p.curToken.LineNumber))
+		return nil
+	}
+	expression.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	inFor := p.inForBlock
+	p.inForBlock = true
+	expression.Block = p.parseBlockStatement()
+	p.inForBlock = inFor
+
+	return expression
+}
+
 func (p *parser) parseIfExpression() ast.Expression {
 	expression := &ast.IfExpression{TokenAble: ast.TokenAble{Token: p.curToken}}
 
@@ -532,6 +838,12 @@ func (p *parser) parseIfExpression() ast.Expression {
 		p.nextToken()
 
 		if p.peekTokenIs(token.IF) {
+			if expression.Type == token.UNLESS {
+				msg := fmt.Sprintf("line %d: syntax error: unless does not support else if", p.curToken.LineNumber)
+				p.errors = append(p.errors, msg)
+				return nil
+			}
+
 			p.nextToken()
 			ifElseExp := p.parseElseIfExpression()
 
@@ -575,6 +887,132 @@ func (p *parser) parseElseIfExpression() *ast.ElseIfExpression {
 	return expression
 }
 
+func (p *parser) parseSwitchExpression() ast.Expression {
+	expression := &ast.SwitchExpression{TokenAble: ast.TokenAble{Token: p.curToken}}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	expression.Subject = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	p.nextToken()
+
+	for !p.curTokenIs(token.RBRACE) {
+		if t := p.switchClause(); t != "" {
+			p.curToken.Type = t
+		}
+
+		switch p.curToken.Type {
+		case token.S_START, token.E_END, token.SEMICOLON:
+			p.nextToken()
+		case token.HTML:
+			// whitespace between the opening brace and the first case
+			if strings.TrimSpace(p.curToken.Literal) != "" {
+				p.errors = append(p.errors, fmt.Sprintf("line %d: expected case or default in switch, got %q", p.curToken.LineNumber, p.curToken.Literal))
+				return nil
+			}
+			p.nextToken()
+		case token.CASE:
+			ce := p.parseCaseExpression()
+			if ce == nil {
+				return nil
+			}
+			expression.Cases = append(expression.Cases, ce)
+		case token.DEFAULT:
+			if expression.Default != nil {
+				p.errors = append(p.errors, fmt.Sprintf("line %d: multiple defaults in switch", p.curToken.LineNumber))
+				return nil
+			}
+			if !p.expectPeek(token.COLON) {
+				return nil
+			}
+			expression.Default = p.parseCaseBlock()
+		default:
+			p.errors = append(p.errors, fmt.Sprintf("line %d: expected case or default in switch, got %s", p.curToken.LineNumber, p.curToken.Type))
+			return nil
+		}
+	}
+
+	return expression
+}
+
+// switchClauses are only keywords at the start of a clause of a switch,
+// so case and default can still be used as names everywhere else.
+var switchClauses = map[string]token.Type{
+	"case":    token.CASE,
+	"default": token.DEFAULT,
+}
+
+// switchClause returns the type of the current token if it starts a case
+// or a default clause of a switch, or an empty type otherwise.
+func (p *parser) switchClause() token.Type {
+	if !p.curTokenIs(token.IDENT) {
+		return ""
+	}
+	return switchClauses[p.curToken.Literal]
+}
+
+func (p *parser) parseCaseExpression() *ast.CaseExpression {
+	expression := &ast.CaseExpression{TokenAble: ast.TokenAble{Token: p.curToken}}
+
+	p.nextToken()
+	expression.Values = append(expression.Values, p.parseExpression(LOWEST))
+
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		p.nextToken()
+		expression.Values = append(expression.Values, p.parseExpression(LOWEST))
+	}
+
+	if !p.expectPeek(token.COLON) {
+		return nil
+	}
+
+	expression.Block = p.parseCaseBlock()
+
+	return expression
+}
+
+// parseCaseBlock parses the statements of a case or default clause. The
+// block ends right before the next case, default or the closing brace of
+// the switch, which is left as the current token.
+func (p *parser) parseCaseBlock() *ast.BlockStatement {
+	block := &ast.BlockStatement{TokenAble: ast.TokenAble{Token: p.curToken}}
+	block.Statements = []ast.Statement{}
+
+	p.nextToken()
+
+	for p.switchClause() == "" && !p.curTokenIs(token.RBRACE) && !p.curTokenIs(token.EOF) {
+		if p.curTokenIs(token.S_START) || p.curTokenIs(token.E_END) {
+			p.nextToken()
+			continue
+		}
+
+		stmt := p.parseStatement()
+		if stmt != nil {
+			block.Statements = append(block.Statements, stmt)
+		}
+
+		p.nextToken()
+	}
+
+	if p.curTokenIs(token.EOF) {
+		p.errors = append(p.errors, fmt.Sprintf("line %d: expected } to close switch, got EOF", p.curToken.LineNumber))
+	}
+
+	return block
+}
+
 func (p *parser) parseBlockStatement() *ast.BlockStatement {
 	block := &ast.BlockStatement{TokenAble: ast.TokenAble{Token: p.curToken}}
 	block.Statements = []ast.Statement{}
@@ -605,7 +1043,9 @@ func (p *parser) parseFunctionLiteral() ast.Expression {
 		return nil
 	}
 
-	lit.Parameters = p.parseFunctionParameters()
+	if !p.parseFunctionParameters(lit) {
+		return nil
+	}
 	p.inForBlock = false
 
 	if !p.expectPeek(token.LBRACE) {
@@ -617,30 +1057,51 @@ func (p *parser) parseFunctionLiteral() ast.Expression {
 	return lit
 }
 
-func (p *parser) parseFunctionParameters() []*ast.Identifier {
-	identifiers := []*ast.Identifier{}
+func (p *parser) parseFunctionParameters(lit *ast.FunctionLiteral) bool {
+	lit.Parameters = []*ast.Identifier{}
 
 	if p.peekTokenIs(token.RPAREN) {
 		p.nextToken()
-		return identifiers
+		return true
 	}
 
-	p.nextToken()
-	ident := &ast.Identifier{TokenAble: ast.TokenAble{Token: p.curToken}, Value: p.curToken.Literal}
-	identifiers = append(identifiers, ident)
-
-	for p.peekTokenIs(token.COMMA) {
+	for {
 		p.nextToken()
-		p.nextToken()
-		ident := &ast.Identifier{TokenAble: ast.TokenAble{Token: p.curToken}, Value: p.curToken.Literal}
-		identifiers = append(identifiers, ident)
-	}
 
-	if !p.expectPeek(token.RPAREN) {
-		return nil
+		if lit.Rest != nil {
+			msg := fmt.Sprintf("line %d: ...%s must be the last parameter", p.curToken.LineNumber, lit.Rest.Value)
+			p.errors = append(p.errors, msg)
+			return false
+		}
+
+		if p.curTokenIs(token.ELLIPSIS) {
+			if !p.expectPeek(token.IDENT) {
+				return false
+			}
+			lit.Rest = &ast.Identifier{TokenAble: ast.TokenAble{Token: p.curToken}, Value: p.curToken.Literal}
+		} else {
+			ident := &ast.Identifier{TokenAble: ast.TokenAble{Token: p.curToken}, Value: p.curToken.Literal}
+			var def ast.Expression
+			if p.peekTokenIs(token.ASSIGN) {
+				p.nextToken()
+				p.nextToken()
+				def = p.parseExpression(LOWEST)
+			} else if len(lit.Defaults) > 0 && lit.Defaults[len(lit.Defaults)-1] != nil {
+				msg := fmt.Sprintf("line %d: parameter %s without a default follows a parameter with a default", p.curToken.LineNumber, ident.Value)
+				p.errors = append(p.errors, msg)
+				return false
+			}
+			lit.Parameters = append(lit.Parameters, ident)
+			lit.Defaults = append(lit.Defaults, def)
+		}
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
 	}
 
-	return identifiers
+	return p.expectPeek(token.RPAREN)
 }
 
 func (p *parser) parseCallExpression(function ast.Expression) ast.Expression {
@@ -658,16 +1119,19 @@ func (p *parser) parseCallExpression(function ast.Expression) ast.Expression {
 	ss := strings.Split(function.String(), ".")
 
 	if len(ss) > 1 {
+		s := strings.TrimSuffix(ss[0], "?")
 		exp.Callee = &ast.Identifier{
-			TokenAble: ast.TokenAble{Token: token.Token{Type: token.IDENT, Literal: ss[0]}},
-			Value:     ss[0],
+			TokenAble: ast.TokenAble{Token: token.Token{Type: token.IDENT, Literal: s}},
+			Value:     s,
 		}
 
 		for i := 1; i < len(ss)-1; i++ {
+			s := strings.TrimSuffix(ss[i], "?")
 			c := &ast.Identifier{
-				TokenAble: ast.TokenAble{Token: token.Token{Type: token.IDENT, Literal: ss[i]}},
-				Value:     ss[i],
+				TokenAble: ast.TokenAble{Token: token.Token{Type: token.IDENT, Literal: s}},
+				Value:     s,
 				Callee:    exp.Callee.(*ast.Identifier),
+				Safe:      strings.HasSuffix(ss[i-1], "?"),
 			}
 			exp.Callee = c
 		}
@@ -676,6 +1140,7 @@ func (p *parser) parseCallExpression(function ast.Expression) ast.Expression {
 			TokenAble: ast.TokenAble{Token: token.Token{Type: token.IDENT, Literal: ss[len(ss)-1]}},
 			Value:     ss[len(ss)-1],
 			Callee:    exp.Callee.(*ast.Identifier),
+			Safe:      strings.HasSuffix(ss[len(ss)-2], "?"),
 		}
 	}
 
@@ -702,16 +1167,19 @@ func (p *parser) parseCallExpression(function ast.Expression) ast.Expression {
 }
 
 func (p *parser) parseExpressionList(end token.Type) []ast.Expression {
-
-	list := []ast.Expression{}
-
 	if p.peekTokenIs(end) {
 		p.nextToken()
-		return list
+		return []ast.Expression{}
 	}
 
 	p.nextToken()
-	list = append(list, p.parseExpression(LOWEST))
+	return p.parseExpressionListFrom(p.parseExpression(LOWEST), end)
+}
+
+// parseExpressionListFrom parses the rest of a list of expressions, whose
+// first expression has been parsed already.
+func (p *parser) parseExpressionListFrom(first ast.Expression, end token.Type) []ast.Expression {
+	list := []ast.Expression{first}
 
 	for p.peekTokenIs(token.COMMA) {
 		p.nextToken()
@@ -728,11 +1196,86 @@ func (p *parser) parseExpressionList(end token.Type) []ast.Expression {
 
 func (p *parser) parseArrayLiteral() ast.Expression {
 	array := &ast.ArrayLiteral{TokenAble: ast.TokenAble{Token: p.curToken}}
-	array.Elements = p.parseExpressionList(token.RBRACKET)
+	if p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+		array.Elements = []ast.Expression{}
+		return array
+	}
+
+	p.nextToken()
+	first := p.parseExpression(LOWEST)
+	if p.peekTokenIs(token.FOR) {
+		return p.parseComprehension(array.Token, nil, first, token.RBRACKET)
+	}
+	array.Elements = p.parseExpressionListFrom(first, token.RBRACKET)
 
 	return array
 }
 
+// parseComprehension parses the rest of a comprehension, the current token
+// is its value, followed by a for.
+func (p *parser) parseComprehension(tok token.Token, key, value ast.Expression, end token.Type) ast.Expression {
+	comp := &ast.Comprehension{
+		TokenAble: ast.TokenAble{Token: tok},
+		Key:       key,
+		Value:     value,
+		KeyName:   "_",
+	}
+	p.nextToken()
+
+	parens := p.peekTokenIs(token.LPAREN)
+	if parens {
+		p.nextToken()
+	}
+	names := []string{}
+	for {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		if strings.Contains(p.curToken.Literal, ".") {
+			p.errors = append(p.errors, fmt.Sprintf("line %d: invalid comprehension variable %s", p.curToken.LineNumber, p.curToken.Literal))
+			return nil
+		}
+		names = append(names, p.curToken.Literal)
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+	if parens && !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	switch len(names) {
+	case 1:
+		comp.ValueName = names[0]
+	case 2:
+		comp.KeyName = names[0]
+		comp.ValueName = names[1]
+	default:
+		p.errors = append(p.errors, fmt.Sprintf("line %d: a comprehension takes one or two variables, got %d", p.curToken.LineNumber, len(names)))
+		return nil
+	}
+
+	if !p.expectPeek(token.IN) {
+		return nil
+	}
+	p.nextToken()
+	comp.Iterable = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.IF) {
+		p.nextToken()
+		p.nextToken()
+		comp.Condition = p.parseExpression(LOWEST)
+	}
+
+	if !p.expectPeek(end) {
+		return nil
+	}
+
+	return comp
+}
+
 func (p *parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	if left == nil {
 		msg := fmt.Sprintf("line %d: syntax error: invalid index access on nil expression", p.curToken.LineNumber)
@@ -742,8 +1285,16 @@ func (p *parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	exp := &ast.IndexExpression{TokenAble: ast.TokenAble{Token: p.curToken}, Left: left}
 
 	p.nextToken()
+	if p.curTokenIs(token.COLON) {
+		return p.parseSliceExpression(exp.Token, left, nil)
+	}
 	exp.Index = p.parseExpression(LOWEST)
 
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		return p.parseSliceExpression(exp.Token, left, exp.Index)
+	}
+
 	if !p.expectPeek(token.RBRACKET) {
 		return nil
 	}
@@ -770,6 +1321,23 @@ func (p *parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	return exp
 }
 
+// parseSliceExpression parses the rest of a slice, the current token is the
+// colon following its low bound.
+func (p *parser) parseSliceExpression(tok token.Token, left, low ast.Expression) ast.Expression {
+	exp := &ast.SliceExpression{TokenAble: ast.TokenAble{Token: tok}, Left: left, Low: low}
+
+	if !p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+		exp.High = p.parseExpression(LOWEST)
+	}
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	return exp
+}
+
 func (p *parser) assignCallee(exp ast.Expression, calleeIdent *ast.Identifier) (assignedCallee ast.Expression) {
 	if exp == nil || calleeIdent == nil {
 		msg := fmt.Sprintf("line %d: syntax error: invalid callee assignment with nil values", p.curToken.LineNumber)
@@ -817,6 +1385,10 @@ func (p *parser) parseHashLiteral() ast.Expression {
 		p.nextToken()
 		value := p.parseExpression(LOWEST)
 
+		if len(hash.Order) == 0 && p.peekTokenIs(token.FOR) {
+			return p.parseComprehension(hash.Token, key, value, token.RBRACE)
+		}
+
 		hash.Pairs[key] = value
 		hash.Order = append(hash.Order, key)
 
@@ -40,6 +40,34 @@ func Test_LetStatements(t *testing.T) {
 	}
 }
 
+func Test_ImportAlias(t *testing.T) {
+	r := require.New(t)
+
+	program, err := parser.Parse(`<% import("macros/forms.plush.html") as forms %>`)
+	r.NoError(err)
+	r.Len(program.Statements, 1)
+
+	letStmt, ok := program.Statements[0].(*ast.LetStatement)
+	r.True(ok)
+	r.Equal("forms", letStmt.Name.Value)
+
+	call, ok := letStmt.Value.(*ast.CallExpression)
+	r.True(ok)
+	r.Equal("import", call.Function.String())
+	r.Len(call.Arguments, 1)
+}
+
+func Test_ImportAlias_Errors(t *testing.T) {
+	r := require.New(t)
+
+	_, err := parser.Parse(`<% import("a") as %>`)
+	r.Error(err)
+
+	_, err = parser.Parse(`<% foo("a") as forms %>`)
+	r.Error(err)
+	r.Contains(err.Error(), "as can only follow import(...)")
+}
+
 func Test_ReturnStatements(t *testing.T) {
 	r := require.New(t)
 	tests := []struct {
@@ -107,6 +135,68 @@ func Test_FloatLiteralExpression(t *testing.T) {
 	r.Equal("1.23", literal.TokenLiteral())
 }
 
+func Test_NumberLiterals(t *testing.T) {
+	r := require.New(t)
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"<% 0xFF %>", 255},
+		{"<% 0Xff %>", 255},
+		{"<% 0b1010 %>", 10},
+		{"<% 0o17 %>", 15},
+		{"<% 010 %>", 10},
+		{"<% 00 %>", 0},
+		{"<% 000 %>", 0},
+		{"<% 00_1 %>", 1},
+		{"<% 1_000_000 %>", 1000000},
+		{"<% 0x_FF_FF %>", 65535},
+		{"<% 1.5e3 %>", 1500.0},
+		{"<% 2E-2 %>", 0.02},
+		{"<% 1e+2 %>", 100.0},
+		{"<% 1_000.5 %>", 1000.5},
+		{"<% .5e1 %>", 5.0},
+	}
+
+	for _, tt := range tests {
+		program, err := parser.Parse(tt.input)
+		r.NoError(err, tt.input)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		switch exp := stmt.Expression.(type) {
+		case *ast.IntegerLiteral:
+			r.Equal(tt.expected, exp.Value, tt.input)
+		case *ast.FloatLiteral:
+			r.Equal(tt.expected, exp.Value, tt.input)
+		default:
+			r.Failf("not a number literal", "%s: %T", tt.input, exp)
+		}
+	}
+}
+
+func Test_NumberLiterals_Errors(t *testing.T) {
+	r := require.New(t)
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"<% 0xZZ %>", `line 1: could not parse "0xZZ" as integer`},
+		{"<% 0b102 %>", `line 1: could not parse "0b102" as integer`},
+		{"<% 1__000 %>", `line 1: could not parse "1__000" as integer`},
+		{"<% 1000_ %>", `line 1: could not parse "1000_" as integer`},
+		{"<% 9223372036854775808 %>", `line 1: could not parse "9223372036854775808" as integer`},
+		{"<% 12px %>", `line 1: could not parse "12px" as integer`},
+		{"<%\n 1.5e %>", `line 2: could not parse "1.5e" as float`},
+		{"<% 1.2.3 %>", `line 1: could not parse "1.2.3" as number`},
+	}
+
+	for _, tt := range tests {
+		_, err := parser.Parse(tt.input)
+		r.Error(err, tt.input)
+		r.Contains(err.Error(), tt.err)
+	}
+}
+
 func Test_PrefixExpressions(t *testing.T) {
 	r := require.New(t)
 	prefixTests := []struct {
@@ -222,6 +312,18 @@ func Test_OperatorPrecedence(t *testing.T) {
 			"5 > 4 == 3 < 4",
 			"((5 > 4) == (3 < 4))",
 		},
+		{
+			"a + b % c * d",
+			"(a + ((b % c) * d))",
+		},
+		{
+			"a * b ** c ** d",
+			"(a * (b ** (c ** d)))",
+		},
+		{
+			"-a ** b + +c",
+			"((-(a ** b)) + (+c))",
+		},
 		{
 			"5 < 4 != 3 > 4",
 			"((5 < 4) != (3 > 4))",
@@ -302,6 +404,34 @@ func Test_OperatorPrecedence(t *testing.T) {
 			"1 != 2 || 2 != 1",
 			"((1 != 2) || (2 != 1))",
 		},
+		{
+			"a || b ? c + 1 : d",
+			"((a || b) ? (c + 1) : d)",
+		},
+		{
+			"a ? b : c ? d : e",
+			"(a ? b : (c ? d : e))",
+		},
+		{
+			"a + b in c == d",
+			"(((a + b) in c) == d)",
+		},
+		{
+			"!(a not in b) && c",
+			"((!(a not in b)) && c)",
+		},
+		{
+			"a ?? b == c ? d : e",
+			"((a ?? (b == c)) ? d : e)",
+		},
+		{
+			"a?.b.c ?? d?.e()",
+			"(a?.b.c ?? d?.e())",
+		},
+		{
+			"a + b | upcase | truncate(c) == d",
+			"((a + ((b | upcase) | truncate(c))) == d)",
+		},
 	}
 
 	for _, tt := range tests {
@@ -554,6 +684,26 @@ func Test_IfElseExpression(t *testing.T) {
 	r.True(testIdentifier(t, alternative.Expression, "y"))
 }
 
+func Test_UnlessElseExpression(t *testing.T) {
+	r := require.New(t)
+	input := `<% unless (x < y) { x } else { y } %>`
+
+	program, err := parser.Parse(input)
+	r.NoError(err)
+
+	r.Len(program.Statements, 1)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+
+	exp := stmt.Expression.(*ast.IfExpression)
+	r.True(exp.Negated())
+
+	r.True(testInfixExpression(t, exp.Condition, "x", "<", "y"))
+	r.Len(exp.Block.Statements, 1)
+	r.Len(exp.ElseBlock.Statements, 1)
+	r.Contains(exp.String(), "unless ((x < y))")
+}
+
 func Test_FunctionLiteralParsing(t *testing.T) {
 	r := require.New(t)
 	input := `<% fn(x, y) { x + y; } %>`
@@ -604,6 +754,24 @@ func Test_FunctionParameterParsing(t *testing.T) {
 	}
 }
 
+func Test_FunctionParameterDefaultsAndRest(t *testing.T) {
+	r := require.New(t)
+
+	program, err := parser.Parse(`<% fn(name, greeting = "Hi", ...rest) {} %>`)
+	r.NoError(err)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	function := stmt.Expression.(*ast.FunctionLiteral)
+
+	r.Len(function.Parameters, 2)
+	r.True(testLiteralExpression(t, function.Parameters[0], "name"))
+	r.True(testLiteralExpression(t, function.Parameters[1], "greeting"))
+	r.Nil(function.Defaults[0])
+	r.Equal("Hi", function.Defaults[1].(*ast.StringLiteral).Value)
+	r.Equal("rest", function.Rest.Value)
+	r.Contains(function.String(), `fn(name, greeting = "Hi", ...rest)`)
+}
+
 func Test_CallExpression(t *testing.T) {
 	r := require.New(t)
 	input := "<% add(1, 2 * 3, 4 + 5); %>"
@@ -869,6 +1037,104 @@ func Test_IndexExpressions(t *testing.T) {
 	r.True(testInfixExpression(t, indexExp.Index, 1, "+", 1))
 }
 
+func Test_InterpolatedString(t *testing.T) {
+	r := require.New(t)
+	input := `<% "btn-#{kind} #{size + 1}!" %>`
+
+	program, err := parser.Parse(input)
+	r.NoError(err)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	str, ok := stmt.Expression.(*ast.InterpolatedString)
+	r.True(ok)
+
+	r.Len(str.Parts, 5)
+	r.Equal("btn-", str.Parts[0].(*ast.StringLiteral).Value)
+	r.True(testIdentifier(t, str.Parts[1], "kind"))
+	r.Equal(" ", str.Parts[2].(*ast.StringLiteral).Value)
+	r.True(testInfixExpression(t, str.Parts[3], "size", "+", 1))
+	r.Equal("!", str.Parts[4].(*ast.StringLiteral).Value)
+	r.Equal(`"btn-#{kind} #{(size + 1)}!"`, str.String())
+}
+
+func Test_InterpolatedString_Errors(t *testing.T) {
+	r := require.New(t)
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{`<% "a #{} b" %>`, "line 1: empty interpolation in string"},
+		{`<% "a #{ b " %>`, "line 1: expected } to close the interpolation in string"},
+	}
+
+	for _, tt := range tests {
+		_, err := parser.Parse(tt.input)
+		r.Error(err)
+		r.Contains(err.Error(), tt.err)
+	}
+}
+
+func Test_SliceExpressions(t *testing.T) {
+	r := require.New(t)
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"<% myArray[1:3] %>", "(myArray[1:3])"},
+		{"<% myArray[:i + 1] %>", "(myArray[:(i + 1)])"},
+		{"<% myArray[-2:] %>", "(myArray[(-2):])"},
+		{"<% myArray[:] %>", "(myArray[:])"},
+	}
+
+	for _, tt := range tests {
+		program, err := parser.Parse(tt.input)
+		r.NoError(err)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		sliceExp, ok := stmt.Expression.(*ast.SliceExpression)
+		r.True(ok)
+
+		r.True(testIdentifier(t, sliceExp.Left, "myArray"))
+		r.Equal(tt.expected, sliceExp.String())
+	}
+
+	_, err := parser.Parse("<% myArray[1:2 %>")
+	r.Error(err)
+}
+
+func Test_Comprehensions(t *testing.T) {
+	r := require.New(t)
+	tests := []struct {
+		input    string
+		expected string
+		hash     bool
+	}{
+		{"<% [x for x in items] %>", "[x for (x) in items]", false},
+		{"<% [x * 2 for (i, x) in items if i > 0] %>", "[(x * 2) for (i, x) in items if (i > 0)]", false},
+		{"<% [x for i, x in items] %>", "[x for (i, x) in items]", false},
+		{"<% {u.ID: u.Name for u in users if u.Active} %>", "{u.ID: u.Name for (u) in users if u.Active}", true},
+	}
+
+	for _, tt := range tests {
+		program, err := parser.Parse(tt.input)
+		r.NoError(err)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		comp, ok := stmt.Expression.(*ast.Comprehension)
+		r.True(ok)
+
+		r.Equal(tt.hash, comp.IsHash())
+		r.Equal(tt.expected, comp.String())
+	}
+
+	_, err := parser.Parse("<% [x for a.b in items] %>")
+	r.Error(err)
+	r.Contains(err.Error(), "invalid comprehension variable a.b")
+
+	_, err = parser.Parse("<% [x for x in items %>")
+	r.Error(err)
+}
+
 func Test_EmptyHashLiteral(t *testing.T) {
 	r := require.New(t)
 	input := "<% {} %>"
@@ -1174,6 +1440,59 @@ func Test_ForExpression(t *testing.T) {
 	r.True(testIdentifier(t, consequence.Expression, "v"))
 }
 
+func Test_ForExpression_Destructuring(t *testing.T) {
+	r := require.New(t)
+	input := `<% for (i, {Name, Email}) in users { Name } %>`
+
+	program, err := parser.Parse(input)
+	r.NoError(err)
+
+	r.Len(program.Statements, 1)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+
+	exp := stmt.Expression.(*ast.ForExpression)
+
+	r.Equal("i", exp.KeyName)
+	r.NotNil(exp.ValuePattern)
+	r.False(exp.ValuePattern.IsArray())
+	r.Len(exp.ValuePattern.Names, 2)
+	r.Equal("Name", exp.ValuePattern.Names[0].Value)
+	r.Equal("Email", exp.ValuePattern.Names[1].Value)
+	r.Equal("users", exp.Iterable.String())
+}
+
+func Test_LetStatement_Destructuring(t *testing.T) {
+	r := require.New(t)
+	tests := []struct {
+		input   string
+		array   bool
+		names   []string
+		pattern string
+	}{
+		{"<% let [first, second] = pair %>", true, []string{"first", "second"}, "[first, second]"},
+		{"<% let {name, email} = user %>", false, []string{"name", "email"}, "{name, email}"},
+	}
+
+	for _, tt := range tests {
+		program, err := parser.Parse(tt.input)
+		r.NoError(err)
+		r.Len(program.Statements, 1)
+
+		letStmt := program.Statements[0].(*ast.LetStatement)
+		r.Nil(letStmt.Name)
+		r.Equal(tt.array, letStmt.Pattern.IsArray())
+		r.Len(letStmt.Pattern.Names, len(tt.names))
+		for i, n := range tt.names {
+			r.Equal(n, letStmt.Pattern.Names[i].Value)
+		}
+		r.Equal(tt.pattern, letStmt.Pattern.String())
+	}
+
+	_, err := parser.Parse("<% let [a, b = pair %>")
+	r.Error(err)
+}
+
 func Test_ForExpression_Split(t *testing.T) {
 	r := require.New(t)
 	input := `<% for (k,v) in anArray { %>
@@ -1195,6 +1514,41 @@ func Test_ForExpression_Split(t *testing.T) {
 	r.Len(exp.Block.Statements, 3)
 }
 
+func Test_WhileExpression(t *testing.T) {
+	r := require.New(t)
+	input := `<% while (i < 10) { %>
+	<p><%= i %></p>
+	<% if (i > 5) { break } %>
+	<% } %>`
+
+	program, err := parser.Parse(input)
+	r.NoError(err)
+
+	r.Len(program.Statements, 1)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+
+	exp := stmt.Expression.(*ast.WhileExpression)
+
+	r.Equal("(i < 10)", exp.Condition.String())
+	r.Len(exp.Block.Statements, 5)
+}
+
+func Test_WhileExpression_Errors(t *testing.T) {
+	r := require.New(t)
+
+	_, err := parser.Parse(`<% while () { } %>`)
+	r.Error(err)
+	r.Contains(err.Error(), "line 1: missing condition in while loop")
+
+	_, err = parser.Parse(`<% while (true) %>`)
+	r.Error(err)
+
+	_, err = parser.Parse(`<% while (true) { } %><% break %>`)
+	r.Error(err)
+	r.Contains(err.Error(), "break is not in a loop")
+}
+
 func Test_ForExpression_Func(t *testing.T) {
 	r := require.New(t)
 	input := `<% for (k,v) in range(1,3) { %>
@@ -1395,3 +1749,48 @@ func Test_ParseCallExpression_NilFunction_ReactLikePatterns_Panic(t *testing.T)
 		})
 	}
 }
+
+func Test_SwitchExpression(t *testing.T) {
+	r := require.New(t)
+	input := `<%= switch (x) { case 1, 2: %>low<% default: %>high<% } %>`
+
+	program, err := parser.Parse(input)
+	r.NoError(err)
+	r.Len(program.Statements, 1)
+
+	stmt := program.Statements[0].(*ast.ReturnStatement)
+	exp := stmt.ReturnValue.(*ast.SwitchExpression)
+
+	r.True(testIdentifier(t, exp.Subject, "x"))
+	r.Len(exp.Cases, 1)
+	r.Len(exp.Cases[0].Values, 2)
+	r.True(testLiteralExpression(t, exp.Cases[0].Values[0], 1))
+	r.True(testLiteralExpression(t, exp.Cases[0].Values[1], 2))
+	r.Len(exp.Cases[0].Block.Statements, 1)
+	r.NotNil(exp.Default)
+	r.Len(exp.Default.Statements, 1)
+}
+
+func Test_SwitchExpression_Unclosed(t *testing.T) {
+	r := require.New(t)
+	_, err := parser.Parse(`<%= switch (x) { case 1: %>low`)
+	r.Error(err)
+	r.Contains(err.Error(), "expected } to close switch")
+}
+
+func Test_SafeNavigationIdentifier(t *testing.T) {
+	r := require.New(t)
+	program, err := parser.Parse(`<% user?.Profile.Avatar %>`)
+	r.NoError(err)
+	r.Len(program.Statements, 1)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	avatar := stmt.Expression.(*ast.Identifier)
+	r.Equal("Avatar", avatar.Value)
+	r.False(avatar.Safe)
+
+	profile := avatar.Callee
+	r.Equal("Profile", profile.Value)
+	r.True(profile.Safe)
+	r.Equal("user", profile.Callee.Value)
+}
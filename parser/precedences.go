@@ -5,17 +5,23 @@ import "github.com/gobuffalo/plush/v5/token"
 const (
 	_           int = iota
 	LOWEST          //
+	TERNARY         // x ? y : z
+	NULLISH         // x ?? y
 	ANDOR           // || or &&
 	EQUALS          // ==
-	LESSGREATER     // > or <
+	LESSGREATER     // > or < or in
 	SUM             // +
-	PRODUCT         // *
+	PRODUCT         // * or / or %
+	POWER           // **
+	FILTER          // x | filter
 	PREFIX          // -X or !X
 	CALL            // myFunction(X)
 	INDEX           // array[index]
 )
 
 var precedences = map[token.Type]int{
+	token.QUESTION: TERNARY,
+	token.NULLISH:  NULLISH,
 	token.EQ:       EQUALS,
 	token.NOT_EQ:   EQUALS,
 	token.MATCHES:  EQUALS,
@@ -25,10 +31,15 @@ var precedences = map[token.Type]int{
 	token.LTEQ:     LESSGREATER,
 	token.GT:       LESSGREATER,
 	token.GTEQ:     LESSGREATER,
+	token.IN:       LESSGREATER,
+	token.NOT:      LESSGREATER,
 	token.PLUS:     SUM,
 	token.MINUS:    SUM,
 	token.SLASH:    PRODUCT,
 	token.ASTERISK: PRODUCT,
+	token.PERCENT:  PRODUCT,
+	token.POWER:    POWER,
+	token.PIPE:     FILTER,
 	token.LPAREN:   CALL,
 	token.LBRACKET: INDEX,
 }
@@ -26,6 +26,14 @@ import (
 	s, err = Render(input, ctx)
 */
 var DefaultTimeFormat = "January 02, 2006 15:04:05 -0700"
+
+// TrimBlocks removes the newline after, and the indentation before, tags
+// that do not print anything such as `<% let x = 1 %>`, comments, or tags
+// opening and closing blocks like `<%= for (x) in xs { %>` and `<% } %>`.
+// This is a **GLOBAL** variable, it applies to every template parsed by the
+// `plush` package that does not use WithTrimBlocks. Single tags can be
+// trimmed with `<%-` and `-%>` instead.
+var TrimBlocks bool
 var PunchHoleCacheLifetime = 1 * time.Minute
 var cacheEnabled bool
 var holeTemplateFileKey = "__plush_internal_hole_render_key_" + fmt.Sprintf("%d", time.Now().UnixNano()) + "__"
@@ -63,34 +71,44 @@ func Parse(input ...string) (*Template, error) {
 	if templateCacheBackend == nil || !cacheEnabled || len(input) == 1 || len(input) > 2 {
 		return NewTemplate(input[0])
 	}
+	return parse(input[0], input[1])
+}
+
+func parse(input, filename string, opts ...TemplateOption) (*Template, error) {
+	if templateCacheBackend == nil || !cacheEnabled {
+		return NewTemplate(input, opts...)
+	}
 
-	filename := input[1]
 	var astKey string
 	isPlushFile := isFilePlush(filename)
 	if isPlushFile {
 
-		astKey = GenerateASTKey(filename)
+		astKey = GenerateASTKey(filename) + newTemplate(input, opts...).cacheKey()
 	}
 	if filename != "" && templateCacheBackend != nil && isPlushFile {
 		t, ok := templateCacheBackend.Get(astKey)
 		if ok {
 			cloned := &Template{
-				Program: t.Program,
-				IsCache: true,
+				Program:    t.Program,
+				IsCache:    true,
+				delims:     t.delims,
+				trimBlocks: t.trimBlocks,
 			}
 			return cloned, nil
 		}
 	}
 
-	t, err := NewTemplate(input[0])
+	t, err := NewTemplate(input, opts...)
 	if err != nil {
 		return t, err
 	}
 	// Cache the AST
 	if cacheEnabled && templateCacheBackend != nil && filename != "" && isPlushFile {
 		astTemplate := &Template{
-			Program: t.Program,
-			IsCache: false,
+			Program:    t.Program,
+			IsCache:    false,
+			delims:     t.delims,
+			trimBlocks: t.trimBlocks,
 		}
 		templateCacheBackend.Set(astKey, astTemplate)
 	}
@@ -127,8 +145,18 @@ func isHole(ctx hctx.Context) bool {
 
 }
 
+// RenderWithOptions renders a template parsed with opts, such as
+// WithDelims or WithTrimBlocks.
+func RenderWithOptions(input string, ctx hctx.Context, opts ...TemplateOption) (string, error) {
+	return render(input, ctx, opts...)
+}
+
 // Render a string using the given context.
 func Render(input string, ctx hctx.Context) (string, error) {
+	return render(input, ctx)
+}
+
+func render(input string, ctx hctx.Context, opts ...TemplateOption) (string, error) {
 	var filename string
 
 	// Extract filename from context if we're not in a hole rendering pass.
@@ -138,13 +166,14 @@ func Render(input string, ctx hctx.Context) (string, error) {
 			filename = cleanFilePath(rawFilename) // ✅ Clean once here
 		}
 	}
+	cacheKey := newTemplate(input, opts...).cacheKey()
 	forceCacheClear := false
 	// Try to render from cache if conditions are met:
 	// - Not in hole rendering pass (prevents infinite recursion)
 	// - Cache is enabled and backend is available
 	// - Template has a filename for cache key
 	if !isHole(ctx) && filename != "" {
-		cacheT, cacheErr := renderFromCache(filename, ctx)
+		cacheT, cacheErr := renderFromCache(filename, cacheKey, ctx)
 		if cacheErr == nil {
 			return cacheT, nil
 		} else if cacheErr == errClearCache {
@@ -152,7 +181,7 @@ func Render(input string, ctx hctx.Context) (string, error) {
 		}
 	}
 
-	t, err := Parse(input, filename)
+	t, err := parse(input, filename, opts...)
 	if err != nil {
 		return "", err
 	}
@@ -177,7 +206,7 @@ func Render(input string, ctx hctx.Context) (string, error) {
 	if (!t.IsCache || forceCacheClear) && cacheEnabled {
 		defer func() {
 			if templateCacheBackend != nil && filename != "" && isPlushFile && len(holeMarkers) > 0 {
-				fullKey := generateFullKey(filename, ctx)
+				fullKey := generateFullKey(filename, ctx) + cacheKey
 				cacheableTemplate := &Template{
 					Skeleton:   t.Skeleton,
 					PunchHole:  holesCopy(t.PunchHole),
@@ -244,7 +273,7 @@ func renderHolesConcurrently(holes []HoleMarker, ctx hctx.Context) []HoleMarker
 		go func(k int, childCtx hctx.Context, h HoleMarker) {
 			defer wg.Done()
 
-			content, err := Render(h.input, childCtx)
+			content, err := render(h.input, childCtx, WithDelims(h.delims.Left, h.delims.Right))
 			if err != nil {
 				content = err.Error() + " in " + currentfileName
 
@@ -307,18 +336,18 @@ func holesCopy(holes []HoleMarker) []HoleMarker {
 // If there is no filename, we should not use the cache.
 // If cache is disabled, we should not use the cache.
 // If there is no templateCacheBackend, we should not use the cache.
-func renderFromCache(filename string, ctx hctx.Context) (string, error) {
+func renderFromCache(filename, cacheKey string, ctx hctx.Context) (string, error) {
 	if filename == "" || !cacheEnabled || templateCacheBackend == nil || isHole(ctx) {
 		return "", errors.New("cache not available")
 	}
 
-	astKey := GenerateASTKey(filename)
+	astKey := GenerateASTKey(filename) + cacheKey
 	_, astExists := templateCacheBackend.Get(astKey)
 	if !astExists {
 		return "", errors.New("AST not cached")
 	}
 
-	fullKey := generateFullKey(filename, ctx)
+	fullKey := generateFullKey(filename, ctx) + cacheKey
 	inCacheTemplate, inCache := templateCacheBackend.Get(fullKey)
 	if inCache &&
 		inCacheTemplate != nil &&
@@ -1,5 +1,7 @@
 package plush
 
+import "github.com/gobuffalo/plush/v5/lexer"
+
 var punch_hole_constant = "<PLUSH_HOLE_%d>"
 
 type HoleMarker struct {
@@ -8,4 +10,5 @@ type HoleMarker struct {
 	start, end  int
 	content     string
 	err         error
+	delims      lexer.Delims
 }
@@ -27,3 +27,57 @@ func Test_Quote_Missing(t *testing.T) {
 		})
 	}
 }
+
+func Test_String_EscapeSequences(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		name     string
+	}{
+		{`<%= raw("line1\nline2") %>`, "line1\nline2", "newline"},
+		{`<%= raw("a\tb\r") %>`, "a\tb\r", "tab_carriage_return"},
+		{`<%= raw("back\\slash") %>`, `back\slash`, "backslash"},
+		{`<%= raw("say \"hi\"") %>`, `say "hi"`, "quote"},
+		{`<%= "caf\u00e9" %>`, "café", "unicode"},
+		{`<%= "\U0001F600" %>`, "😀", "unicode_long"},
+		{`<%= "\x41\x42" %>`, "AB", "hex"},
+		{`<%= "caf\xc3\xa9" %>`, "café", "hex_bytes"},
+		{`<%= "\101" %>`, "A", "octal"},
+		{`<%= len("\u00e9") %>`, "2", "unicode_bytes"},
+		{`<%= "\#{kind}" %>`, "#{kind}", "interpolation"},
+		{"<%= raw(`a\\nb`) %>", `a\nb`, "backtick_literal"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			s, err := plush.Render(tc.input, plush.NewContext())
+			r.NoError(err)
+			r.Equal(tc.expected, s)
+		})
+	}
+}
+
+func Test_String_EscapeSequences_Errors(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+		name  string
+	}{
+		{`<%= "\q" %>`, `line 1: invalid escape sequence \q in string`, "unknown"},
+		{`<%= "\u00zz" %>`, `line 1: invalid escape sequence \u00zz in string`, "unicode"},
+		{`<%= "\xg" %>`, `line 1: invalid escape sequence \xg in string`, "hex"},
+		{`<%= "\uD800" %>`, `line 1: invalid escape sequence \uD800 in string`, "surrogate"},
+		{`<%= "don\'t" %>`, `line 1: invalid escape sequence \' in string`, "single_quote"},
+		{"<%= \"a\" %>\n<%= \"#{1} \\z\" %>", `line 2: invalid escape sequence \z in string`, "interpolated"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			_, err := plush.Render(tc.input, plush.NewContext())
+			r.Error(err)
+			r.Contains(err.Error(), tc.err)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package plush_test
+
+import (
+	"testing"
+
+	"github.com/gobuffalo/plush/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Raw(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		name     string
+	}{
+		{`<%raw%><%= name %> {{ name }}<%endraw%>`, `<%= name %> {{ name }}`, "expression"},
+		{"<%raw%>\n<% if (x) { %>\n<%endraw%>", "\n<% if (x) { %>\n", "multiline"},
+		{`<%raw%><div x-data="{ a: '<b>' }"></div><%endraw%>`, `<div x-data="{ a: '<b>' }"></div>`, "not_escaped"},
+		{`a<%raw%><%%><%endraw%>b<%= name %>`, `a<%%>bmark`, "followed_by_tag"},
+		{`<%= name %><%raw%><%= name %><%endraw%><%raw%>!<%endraw%>`, `mark<%= name %>!`, "multiple"},
+		{`<%= if (true) { %><%raw%><%= name %><%endraw%><% } %>`, `<%= name %>`, "in_block"},
+		{`a \<%raw%> b`, `a <%raw%> b`, "escaped"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			ctx := plush.NewContext()
+			ctx.Set("name", "mark")
+			s, err := plush.Render(tc.input, ctx)
+			r.NoError(err)
+			r.Equal(tc.expected, s)
+		})
+	}
+}
+
+func Test_Raw_Unclosed(t *testing.T) {
+	r := require.New(t)
+	_, err := plush.Render("a\n<%raw%><%= 1 %>", plush.NewContext())
+	r.Error(err)
+	r.Contains(err.Error(), "line 2: <%raw%> is missing its closing <%endraw%>")
+}
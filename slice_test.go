@@ -0,0 +1,65 @@
+package plush_test
+
+import (
+	"testing"
+
+	"github.com/gobuffalo/plush/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Slice(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		name     string
+		success  bool
+	}{
+		{`<%= items[1:3] %>`, "bc", "low_high", true},
+		{`<%= items[:2] %>`, "ab", "no_low", true},
+		{`<%= items[3:] %>`, "de", "no_high", true},
+		{`<%= items[:] %>`, "abcde", "no_bounds", true},
+		{`<%= items[-2:] %>`, "de", "negative_low", true},
+		{`<%= items[:-3] %>`, "ab", "negative_high", true},
+		{`<%= items[:50] %>`, "abcde", "high_clamped", true},
+		{`<%= items[-50:1] %>`, "a", "low_clamped", true},
+		{`<%= items[3:1] %>`, "", "low_past_high", true},
+		{`<%= len(items[1:]) %>`, "4", "slice_len", true},
+		{`<%= numbers[1:][0] %><%= len(numbers[1:]) %>`, "22", "array", true},
+		{`<%= [1, 2, 3][1:2] %>`, "2", "array_literal", true},
+		{`<% let i = 1 %><%= items[i:i+2] %>`, "bc", "expression_bounds", true},
+		{`<%= items[-1] %>`, "e", "negative_index", true},
+		{`<%= numbers[-3] %>`, "1", "negative_index_array", true},
+		{`<% let a = [1, 2, 3] %><% a[-1] = 4 %><%= a %>`, "124", "negative_index_update", true},
+		{`<%= word[1] %>`, "é", "string_index", true},
+		{`<%= word[-1] %>`, "o", "string_negative_index", true},
+		{`<%= word[1:3] %>`, "él", "string_slice", true},
+		{`<%= word[:-2] %>`, "hél", "string_negative_slice", true},
+		{`<%= "abc"[:10] %>`, "abc", "string_slice_clamped", true},
+		{`<%= items[5] %>`, "line 1: array index out of bounds, got index 5, while array size is 5", "index_past_end", false},
+		{`<%= items[-6] %>`, "line 1: array index out of bounds, got index -6, while array size is 5", "negative_index_past_start", false},
+		{`<%= word[5] %>`, "line 1: string index out of bounds, got index 5, while string length is 5", "string_index_past_end", false},
+		{`<%= word["a"] %>`, "can't access String with a non int Index (a)", "string_non_int_index", false},
+		{`<%= items["a":] %>`, "can't slice with a non int bound (a)", "non_int_bound", false},
+		{`<%= {"a": 1}[1:] %>`, "could not slice map[string]interface {}", "map", false},
+		{"<% if (true) { %>a<% } %>\n\n<%= items[9] %>", "line 3: array index out of bounds", "line_after_block", false},
+		{"<%= for (x) in items { %>\n<%= items[9] %>\n<% } %>", "line 2: array index out of bounds", "line_in_block", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			ctx := plush.NewContext()
+			ctx.Set("items", []string{"a", "b", "c", "d", "e"})
+			ctx.Set("numbers", [3]int{1, 2, 3})
+			ctx.Set("word", "héllo")
+			s, err := plush.Render(tc.input, ctx)
+			if tc.success {
+				r.NoError(err)
+				r.Equal(tc.expected, s)
+			} else {
+				r.Error(err)
+				r.Contains(err.Error(), tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package plush_test
+
+import (
+	"testing"
+
+	"github.com/gobuffalo/plush/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Switch_Expression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		name     string
+		success  bool
+	}{
+		{`<%= switch ("a") { case "a": %>A<% case "b": %>B<% } %>`, "A", "first_case", true},
+		{`<%= switch ("c") { case "a": %>A<% case "b", "c": %>BC<% } %>`, "BC", "multiple_values", true},
+		{`<%= switch ("z") { case "a": %>A<% default: %>D<% } %>`, "D", "default", true},
+		{`<%= switch ("z") { default: %>D<% case "z": %>Z<% } %>`, "Z", "default_first", true},
+		{`<%= switch ("z") { case "a": %>A<% } %>`, "", "no_match", true},
+		{`<%= switch (1 + 1) { case 1: return "one" case 2: return "two" } %>`, "two", "return", true},
+		{`<%= switch (x) { case nil: %>nil<% default: %>D<% } %>`, "nil", "unknown_identifier", true},
+		{`<%= switch (2) { %>
+  <% case 1: %>one<% case 2: %>two<% } %>`, "two", "whitespace", true},
+		{`<% let h = {case: 1, default: 2} %><%= h["case"] %><%= h.default %>`, "12", "hash_keys_outside_switch", true},
+		{`<% let case = 1 %><% let default = "d" %><%= switch (case) { case 1: %><%= default %><% default: %>D<% } %>`, "d", "names_outside_clauses", true},
+		{`<%= switch (1) { case true: %>x<% } %>`, "line 1: unable to operate (==) on int and bool", "mismatched_types", false},
+		{`<%= switch (1) { default: %>a<% default: %>b<% } %>`, "line 1: multiple defaults in switch", "multiple_defaults", false},
+		{`<%= switch (1) { %>oops<% case 1: %>a<% } %>`, `line 1: expected case or default in switch, got "oops"`, "html_before_case", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			s, err := plush.Render(tc.input, plush.NewContext())
+			if tc.success {
+				r.NoError(err)
+				r.Equal(tc.expected, s)
+			} else {
+				r.Error(err)
+				r.Contains(err.Error(), tc.expected)
+			}
+		})
+	}
+}
+
+func Test_Switch_Expression_Scope(t *testing.T) {
+	r := require.New(t)
+	input := `<% let k = "b" %><%= switch (k) { case "a": %>A<% case "b": let k = "x" %><%= k %><% } %><%= k %>`
+	s, err := plush.Render(input, plush.NewContext())
+	r.NoError(err)
+	r.Equal("xb", s)
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gobuffalo/plush/v5/ast"
 	"github.com/gobuffalo/plush/v5/helpers/hctx"
+	"github.com/gobuffalo/plush/v5/lexer"
 
 	"github.com/gobuffalo/plush/v5/parser"
 )
@@ -18,15 +19,40 @@ type Template struct {
 	Skeleton   string
 	IsCache    bool
 	LastCached time.Time
+
+	delims     lexer.Delims
+	trimBlocks bool
+}
+
+// TemplateOption changes how a Template is parsed.
+type TemplateOption func(*Template)
+
+// WithDelims parses the template using left and right as the tag
+// delimiters instead of `<%` and `%>`. Expression, comment and hole tags
+// become left followed by `=`, `#` and `H`, e.g. `{{= }}`. Empty
+// delimiters are ignored.
+func WithDelims(left, right string) TemplateOption {
+	return func(t *Template) {
+		if left == "" || right == "" {
+			return
+		}
+		t.delims = lexer.Delims{Left: left, Right: right}
+	}
+}
+
+// WithTrimBlocks turns trim blocks on or off for the template, instead
+// of using the global TrimBlocks.
+func WithTrimBlocks(on bool) TemplateOption {
+	return func(t *Template) {
+		t.trimBlocks = on
+	}
 }
 
 // NewTemplate from the input string. Adds all of the
 // global helper functions from "Helpers", this function does not
 // cache the template.
-func NewTemplate(input string) (*Template, error) {
-	t := &Template{
-		Input: input,
-	}
+func NewTemplate(input string, opts ...TemplateOption) (*Template, error) {
+	t := newTemplate(input, opts...)
 
 	err := t.Parse()
 	if err != nil {
@@ -36,6 +62,31 @@ func NewTemplate(input string) (*Template, error) {
 	return t, nil
 }
 
+// newTemplate returns the unparsed template for input with opts applied.
+func newTemplate(input string, opts ...TemplateOption) *Template {
+	t := &Template{
+		Input:      input,
+		trimBlocks: TrimBlocks,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// cacheKey distinguishes the cached templates of a file parsed with
+// different options.
+func (t *Template) cacheKey() string {
+	var key string
+	if t.delims != (lexer.Delims{}) {
+		key += ":delims:" + t.delims.Left + ":" + t.delims.Right
+	}
+	if t.trimBlocks {
+		key += ":trim"
+	}
+	return key
+}
+
 // Parse the template this can be called many times
 // as a successful result is cached and is used on subsequent
 // uses.
@@ -44,7 +95,7 @@ func (t *Template) Parse() error {
 		return nil
 	}
 
-	program, err := parser.Parse(t.Input)
+	program, err := parser.ParseWithOptions(t.Input, lexer.Options{TrimBlocks: t.trimBlocks, Delims: t.delims})
 	if err != nil {
 		return err
 	}
@@ -61,8 +112,10 @@ func (t *Template) Exec(ctx hctx.Context) (string, []HoleMarker, error) {
 	}
 
 	ev := compiler{
-		ctx:     ctx,
-		program: t.Program,
+		ctx:        ctx,
+		program:    t.Program,
+		delims:     t.delims,
+		trimBlocks: t.trimBlocks,
 	}
 
 	s, err := ev.compile()
@@ -72,8 +125,10 @@ func (t *Template) Exec(ctx hctx.Context) (string, []HoleMarker, error) {
 // Clone a template. This is useful for defining helpers on per "instance" of the template.
 func (t *Template) Clone() *Template {
 	t2 := &Template{
-		Input:   t.Input,
-		Program: t.Program,
+		Input:      t.Input,
+		Program:    t.Program,
+		delims:     t.delims,
+		trimBlocks: t.trimBlocks,
 	}
 	return t2
 }
@@ -0,0 +1,64 @@
+package plush_test
+
+import (
+	"testing"
+
+	"github.com/gobuffalo/plush/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Ternary_Expression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		name     string
+	}{
+		{`<%= true ? "a" : "b" %>`, "a", "true"},
+		{`<%= false ? "a" : "b" %>`, "b", "false"},
+		{`<%= 1 > 2 ? "a" : "b" %>`, "b", "infix_condition"},
+		{`<%= 1 < 2 && 2 < 3 ? "a" : "b" %>`, "a", "logical_condition"},
+		{`<%= false ? "a" : true ? "b" : "c" %>`, "b", "nested_alternative"},
+		{`<%= true ? false ? "a" : "b" : "c" %>`, "b", "nested_consequence"},
+		{`<%= unknown ? "a" : "b" %>`, "b", "unknown_identifier"},
+		{`<%= true ? 1 + 2 : 3 %>`, "3", "infix_branch"},
+		{`<% let x = true ? "a" : "b" %><%= x %>`, "a", "let"},
+		{`<%= {class: true ? "on" : "off"}["class"] %>`, "on", "hash_value"},
+		{`<%= if (true ? true : false) { %>good<% } %>`, "good", "if_condition"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			s, err := plush.Render(tc.input, plush.NewContext())
+			r.NoError(err)
+			r.Equal(tc.expected, s)
+		})
+	}
+}
+
+func Test_Ternary_Expression_Lazy(t *testing.T) {
+	r := require.New(t)
+	ctx := plush.NewContext()
+	calls := 0
+	ctx.Set("touch", func() string {
+		calls++
+		return "touched"
+	})
+
+	s, err := plush.Render(`<%= true ? "a" : touch() %>`, ctx)
+	r.NoError(err)
+	r.Equal("a", s)
+	r.Equal(0, calls)
+
+	s, err = plush.Render(`<%= false ? "a" : touch() %>`, ctx)
+	r.NoError(err)
+	r.Equal("touched", s)
+	r.Equal(1, calls)
+}
+
+func Test_Ternary_Expression_MissingColon(t *testing.T) {
+	r := require.New(t)
+	_, err := plush.Render(`<%= true ? "a" %>`, plush.NewContext())
+	r.Error(err)
+	r.Contains(err.Error(), "expected next token to be :")
+}
@@ -33,3 +33,62 @@ func Test_Default_Time_Format(t *testing.T) {
 	r.NoError(err)
 	r.Equal("2013-03-Feb", s)
 }
+
+func Test_Time_Operators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		name     string
+		success  bool
+	}{
+		{`<%= published < now %>`, "true", "less_than", true},
+		{`<%= published > now %>`, "false", "greater_than", true},
+		{`<%= now <= now %>`, "true", "less_than_or_equal", true},
+		{`<%= now >= expires %>`, "false", "greater_than_or_equal", true},
+		{`<%= now == nowPtr %>`, "true", "equal_pointer", true},
+		{`<%= now != published %>`, "true", "not_equal", true},
+		{`<%= expires - now %>`, "36h0m0s", "time_minus_time", true},
+		{`<%= now + duration("2h") %>`, "2024-01-02 05:04", "time_plus_duration", true},
+		{`<%= duration("2h") + now %>`, "2024-01-02 05:04", "duration_plus_time", true},
+		{`<%= now - duration("30m") %>`, "2024-01-02 02:34", "time_minus_duration", true},
+		{`<%= duration("1h") + duration("30m") %>`, "1h30m0s", "duration_plus_duration", true},
+		{`<%= duration("1h") > duration("30m") %>`, "true", "duration_comparison", true},
+		{`<%= duration("1h") * 3 %>`, "3h0m0s", "duration_times_int", true},
+		{`<%= 2 * duration("1h") %>`, "2h0m0s", "int_times_duration", true},
+		{`<%= duration("1h") * 1.5 %>`, "1h30m0s", "duration_times_float", true},
+		{`<%= duration("1h") / 4 %>`, "15m0s", "duration_divided", true},
+		{`<%= if (expires - now < duration("48h")) { %>soon<% } %>`, "soon", "condition", true},
+		{`<%= nilPtr == nil %>`, "true", "nil_pointer_equal_nil", true},
+		{`<%= nilPtr == now %>`, "false", "nil_pointer_equal_time", true},
+		{`<%= now != nilPtr %>`, "true", "time_not_equal_nil_pointer", true},
+		{`<%= nilPtr < now %>`, "unknown operator '<' on '<nil>' and 'time.Time'", "nil_pointer_less_than", false},
+		{`<%= now - nilPtr %>`, "unknown operator '-' on 'time.Time' and '<nil>'", "time_minus_nil_pointer", false},
+		{`<%= now + 1 %>`, "unable to operate (+) on time.Time and int", "time_plus_int", false},
+		{`<%= now * now %>`, "unable to operate (*) on time.Time and time.Time", "time_times_time", false},
+		{`<%= duration("1h") + 1 %>`, "unable to operate (+) on time.Duration and int", "duration_plus_int", false},
+		{`<%= duration("1h") / 0 %>`, "division by zero", "duration_divided_by_zero", false},
+		{`<%= duration("soon") %>`, `time: invalid duration "soon"`, "invalid_duration", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+			ctx := plush.NewContext()
+			ctx.Set("TIME_FORMAT", "2006-01-02 15:04")
+			ctx.Set("now", now)
+			ctx.Set("nowPtr", &now)
+			ctx.Set("nilPtr", (*time.Time)(nil))
+			ctx.Set("published", now.Add(-time.Hour))
+			ctx.Set("expires", now.Add(36*time.Hour))
+			s, err := plush.Render(tc.input, ctx)
+			if tc.success {
+				r.NoError(err)
+				r.Equal(tc.expected, s)
+			} else {
+				r.Error(err)
+				r.Contains(err.Error(), tc.expected)
+			}
+		})
+	}
+}
@@ -13,6 +13,11 @@ const (
 	HTML     = "HTML"     // <p>adf</p>
 	DOT      = "DOT"      // .23
 
+	// Interpolated strings, "foo #{a} bar #{b} baz"
+	I_STRING     = "I_STRING"     // "foo #{
+	I_STRING_MID = "I_STRING_MID" // } bar #{
+	I_STRING_END = "I_STRING_END" // } baz"
+
 	// Operators
 	ASSIGN   = "="
 	PLUS     = "+"
@@ -21,6 +26,7 @@ const (
 	ASTERISK = "*"
 	SLASH    = "/"
 	PERCENT  = "%"
+	POWER    = "**"
 
 	LT   = "<"
 	LTEQ = "<="
@@ -33,6 +39,11 @@ const (
 	OR      = "||"
 	MATCHES = "~="
 
+	QUESTION = "?"
+	NULLISH  = "??"
+	PIPE     = "|"
+	ELLIPSIS = "..."
+
 	// Delimiters
 
 	S_START = "<%"
@@ -58,10 +69,16 @@ const (
 	TRUE     = "TRUE"
 	FALSE    = "FALSE"
 	IF       = "IF"
+	UNLESS   = "UNLESS"
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
 	FOR      = "FOR"
+	WHILE    = "WHILE"
 	IN       = "IN"
+	NOT      = "NOT"
 	CONTINUE = "CONTINUE"
 	BREAK    = "BREAK"
+	SWITCH   = "SWITCH"
+	CASE     = "CASE"
+	DEFAULT  = "DEFAULT"
 )
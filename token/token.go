@@ -17,12 +17,15 @@ var keywords = map[string]Type{
 	"true":     TRUE,
 	"false":    FALSE,
 	"if":       IF,
+	"unless":   UNLESS,
 	"else":     ELSE,
 	"return":   RETURN,
 	"for":      FOR,
+	"while":    WHILE,
 	"in":       IN,
 	"continue": CONTINUE,
 	"break":    BREAK,
+	"switch":   SWITCH,
 }
 
 // LookupIdent an ident and return a keyword type, or a plain ident
@@ -0,0 +1,81 @@
+package plush_test
+
+import (
+	"testing"
+
+	"github.com/gobuffalo/plush/v5"
+	"github.com/gobuffalo/plush/v5/helpers/meta"
+	"github.com/gobuffalo/plush/v5/templatecache/inmemory"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Trim_Markers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		name     string
+	}{
+		{"a  \n  <%=- 1 %>", "a1", "trim_left"},
+		{"<%= 1 -%>  \n  b", "1b", "trim_right"},
+		{"a\n<%=- 1 -%>\nb", "a1b", "trim_both"},
+		{"a\n<%- let x = 1 -%>\n<%= x %>", "a1", "statement"},
+		{"a\n<%#- comment -%>\nb", "ab", "comment"},
+		{"<% let x = 1-%>\nb", "b", "trim_right_after_identifier"},
+		{"<% let x-y = 1 %><%= x-y -%>\nb", "1b", "dashed_identifier"},
+		{"<ul>\n<%=- for (i) in [1, 2] { -%>\n  <li><%= i %></li>\n<%- } -%>\n</ul>", "<ul><li>1</li><li>2</li></ul>", "loop"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			s, err := plush.Render(tc.input, plush.NewContext())
+			r.NoError(err)
+			r.Equal(tc.expected, s)
+		})
+	}
+}
+
+func Test_Trim_Blocks(t *testing.T) {
+	r := require.New(t)
+	input := `items:
+  <%= for (i, v) in items { %>
+  - <%= v %>
+  <% } %>
+<%# a comment %>
+<% let total = len(items) %>
+total: <%= total %>
+`
+	ctx := plush.NewContext()
+	ctx.Set("items", []string{"a", "b"})
+	s, err := plush.RenderWithOptions(input, ctx, plush.WithTrimBlocks(true))
+	r.NoError(err)
+	r.Equal("items:\n  - a\n  - b\ntotal: 2\n", s)
+}
+
+func Test_Trim_Blocks_Cached(t *testing.T) {
+	r := require.New(t)
+	plush.UseTemplateCache(t, inmemory.NewMemoryCache())
+	input := "<% let x = 1 %>\n<%= x %>\n"
+
+	ctx := plush.NewContext()
+	ctx.Set(meta.TemplateFileKey, "trim.plush")
+	s, err := plush.Render(input, ctx)
+	r.NoError(err)
+	r.Equal("\n1\n", s)
+
+	s, err = plush.RenderWithOptions(input, ctx, plush.WithTrimBlocks(true))
+	r.NoError(err)
+	r.Equal("1\n", s)
+
+	s, err = plush.Render(input, ctx)
+	r.NoError(err)
+	r.Equal("\n1\n", s)
+}
+
+func Test_Trim_Blocks_Disabled(t *testing.T) {
+	r := require.New(t)
+	input := "<% let x = 1 %>\n<%= x %>\n"
+	s, err := plush.Render(input, plush.NewContext())
+	r.NoError(err)
+	r.Equal("\n1\n", s)
+}
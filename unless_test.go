@@ -0,0 +1,41 @@
+package plush_test
+
+import (
+	"testing"
+
+	"github.com/gobuffalo/plush/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Unless_Condition(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		name     string
+		success  bool
+	}{
+		{`<%= unless (false) { return "good"} else { return "bad"} %>`, "good", "unless_else_false", true},
+		{`<%= unless (true) { return "good"} else { return "bad"} %>`, "bad", "unless_else_true", true},
+		{`<%= unless (false) { %>good<% } %>`, "good", "value_from_template_html", true},
+		{`<%= unless (true) { %>good<% } %>`, "", "unless_true", true},
+		{`<%= unless (!true) { %>good<% } %>`, "good", "unless_bang_true", true},
+		{`<%= unless (names) { %>good<% } %>`, "good", "unknown_identifier", true},
+		{`<%= unless (1 == 2 || 2 == 1) { %>good<% } %>`, "good", "logical_false_or_false", true},
+		{`<% let admin = true %><%= unless (admin) { %>user<% } else { %>admin<% } %>`, "admin", "let_var", true},
+		{`<%= unless (true) { %>a<% } else if (true) { %>b<% } %>`, "line 1: syntax error: unless does not support else if", "else_if", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			s, err := plush.Render(tc.input, plush.NewContext())
+			if tc.success {
+				r.NoError(err)
+				r.Equal(tc.expected, s)
+			} else {
+				r.Error(err)
+				r.Contains(err.Error(), tc.expected)
+			}
+		})
+	}
+}
@@ -2,23 +2,38 @@ package plush
 
 import (
 	"bytes"
+	"fmt"
 	"strings"
 
 	"github.com/gobuffalo/plush/v5/ast"
+	"github.com/gobuffalo/plush/v5/helpers/hctx"
 )
 
 type userFunction struct {
 	Parameters []*ast.Identifier
+	Defaults   []ast.Expression
+	Rest       *ast.Identifier
 	Block      *ast.BlockStatement
+
+	// env is the context the function was defined in, the function's
+	// block runs in a new scope of it.
+	env hctx.Context
 }
 
 func (f *userFunction) String() string {
 	var out bytes.Buffer
 
 	params := []string{}
-	for _, p := range f.Parameters {
+	for i, p := range f.Parameters {
+		if i < len(f.Defaults) && f.Defaults[i] != nil {
+			params = append(params, p.String()+" = "+f.Defaults[i].String())
+			continue
+		}
 		params = append(params, p.String())
 	}
+	if f.Rest != nil {
+		params = append(params, "..."+f.Rest.String())
+	}
 
 	out.WriteString("fn")
 	out.WriteString("(")
@@ -29,3 +44,33 @@ func (f *userFunction) String() string {
 
 	return out.String()
 }
+
+// checkArity returns an error naming the function when it can not be
+// called with n arguments.
+func (f *userFunction) checkArity(name string, n int) error {
+	required := 0
+	for i := range f.Parameters {
+		if i >= len(f.Defaults) || f.Defaults[i] == nil {
+			required++
+		}
+	}
+	max := len(f.Parameters)
+
+	var expected string
+	switch {
+	case f.Rest != nil:
+		expected = fmt.Sprintf("at least %d", required)
+	case required == max:
+		expected = fmt.Sprintf("%d", max)
+	default:
+		expected = fmt.Sprintf("%d to %d", required, max)
+	}
+
+	if n < required {
+		return fmt.Errorf("%s: too few arguments (%d for %s)", name, n, expected)
+	}
+	if f.Rest == nil && n > max {
+		return fmt.Errorf("%s: too many arguments (%d for %s)", name, n, expected)
+	}
+	return nil
+}
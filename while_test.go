@@ -0,0 +1,41 @@
+package plush_test
+
+import (
+	"testing"
+
+	"github.com/gobuffalo/plush/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Render_While(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		name     string
+	}{
+		{`<% let i = 0 %><%= while (i < 5) { %><%= i %><% i = i + 1 %><% } %>`, "01234", "counter"},
+		{`<% let i = 10 %><%= while (i > 0) { i = i - 3; return i } %>`, "741-2", "count_down"},
+		{`<% let i = 0 %><%= while (true) { i = i + 1; if (i > 3) { break }; return i } %>`, "123", "break"},
+		{`<% let i = 0 %><%= while (i < 6) { i = i + 1; if (i % 2 == 0) { continue }; return i } %>`, "135", "continue"},
+		{`<%= while (false) { %>never<% } %>done`, "done", "never_runs"},
+		{`<%= while (missing) { %>never<% } %>done`, "done", "unknown_condition"},
+		{`<% let i = 0 %><%= while (i < 2) { let j = i; i = i + 1; return j } %><%= j ?? "-" %>`, "01-", "scoped_variables"},
+		{`<% let i = 0 %><%= while (i < 2) { i = i + 1 %><%= for (v) in [1, 2] { return v * i } %><% } %>`, "1224", "nested_for"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+			s, err := plush.Render(tc.input, plush.NewContext())
+			r.NoError(err)
+			r.Equal(tc.expected, s)
+		})
+	}
+}
+
+func Test_Render_While_Errors(t *testing.T) {
+	r := require.New(t)
+	_, err := plush.Render(`<% let i = 0 %><%= while (i < 2) { i = i + 1; return foo() } %>`, plush.NewContext())
+	r.Error(err)
+	r.Contains(err.Error(), `"foo": unknown identifier`)
+}